@@ -3,11 +3,38 @@ package mongosteps
 import (
 	"context"
 
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 )
 
 type queryerCtxKey struct{}
 
+type searchQueryCtxKey struct{}
+
+type writeResultCtxKey struct{}
+
+type writeErrorCtxKey struct{}
+
+type cleanUpsCtxKey struct{}
+
+type namedResultsCtxKey struct{}
+
+// searchQuery is the latest search in the scenario, it is used to refresh the search result.
+type searchQuery struct {
+	Database   string
+	Collection string
+	Find       *findQuery
+	Pipeline   mongo.Pipeline
+}
+
+// writeResult is the outcome of the latest write in the scenario.
+type writeResult struct {
+	Matched  int64
+	Modified int64
+	Upserted int64
+	Deleted  int64
+}
+
 func contextWithDocs(ctx context.Context, docs []bsoncore.Document) context.Context {
 	return context.WithValue(ctx, queryerCtxKey{}, docs)
 }
@@ -20,3 +47,83 @@ func docsFromContext(ctx context.Context) []bsoncore.Document {
 
 	return q
 }
+
+func contextWithSearchQuery(ctx context.Context, q searchQuery) context.Context {
+	return context.WithValue(ctx, searchQueryCtxKey{}, q)
+}
+
+func searchQueryFromContext(ctx context.Context) (searchQuery, bool) {
+	q, ok := ctx.Value(searchQueryCtxKey{}).(searchQuery)
+
+	return q, ok
+}
+
+func contextWithWriteResult(ctx context.Context, r writeResult) context.Context {
+	return context.WithValue(ctx, writeResultCtxKey{}, r)
+}
+
+func writeResultFromContext(ctx context.Context) (writeResult, bool) {
+	r, ok := ctx.Value(writeResultCtxKey{}).(writeResult)
+
+	return r, ok
+}
+
+func contextWithWriteError(ctx context.Context, e writeError) context.Context {
+	return context.WithValue(ctx, writeErrorCtxKey{}, e)
+}
+
+func writeErrorFromContext(ctx context.Context) (writeError, bool) {
+	e, ok := ctx.Value(writeErrorCtxKey{}).(writeError)
+
+	return e, ok
+}
+
+// contextWithCleanUps registers the collections of the database to clean up after the scenario.
+func contextWithCleanUps(ctx context.Context, dbName string, collections ...string) context.Context {
+	current, _ := ctx.Value(cleanUpsCtxKey{}).(map[string][]string) // nolint: errcheck
+	cleanUps := make(map[string][]string, len(current)+1)
+
+	for name, c := range current {
+		cleanUps[name] = c
+	}
+
+	registered := append([]string(nil), cleanUps[dbName]...)
+
+	for _, collection := range collections {
+		if !containsString(registered, collection) {
+			registered = append(registered, collection)
+		}
+	}
+
+	cleanUps[dbName] = registered
+
+	return context.WithValue(ctx, cleanUpsCtxKey{}, cleanUps)
+}
+
+func cleanUpsFromContext(ctx context.Context, dbName string) []string {
+	cleanUps, _ := ctx.Value(cleanUpsCtxKey{}).(map[string][]string) // nolint: errcheck
+
+	return cleanUps[dbName]
+}
+
+// contextWithNamedResult keeps the documents of a search under a name, so that several results of the scenario can be
+// compared.
+func contextWithNamedResult(ctx context.Context, name string, docs []bsoncore.Document) context.Context {
+	current, _ := ctx.Value(namedResultsCtxKey{}).(map[string][]bsoncore.Document) // nolint: errcheck
+	results := make(map[string][]bsoncore.Document, len(current)+1)
+
+	for n, d := range current {
+		results[n] = d
+	}
+
+	results[name] = docs
+
+	return context.WithValue(ctx, namedResultsCtxKey{}, results)
+}
+
+func namedResultFromContext(ctx context.Context, name string) ([]bsoncore.Document, bool) {
+	results, _ := ctx.Value(namedResultsCtxKey{}).(map[string][]bsoncore.Document) // nolint: errcheck
+	docs, ok := results[name]
+
+	return docs, ok
+}
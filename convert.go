@@ -1,22 +1,295 @@
 package mongosteps
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cucumber/godog"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 )
 
+var (
+	// generatorPattern matches the generator expressions, like {"$now": "-24h"}, {"$newOid": "alias"} or {"$uuid": {}}.
+	generatorPattern = regexp.MustCompile(`\{\s*"\$(now|newOid|uuid)"\s*:\s*(\{\s*\}|"[^"\\]*")\s*\}`)
+
+	// aliasPattern matches the alias of a generated value.
+	aliasPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// docStringResolver resolves the variables and the generated values of a DocString before it is parsed.
+type docStringResolver func(data *godog.DocString) (*godog.DocString, error)
+
 func stringToDocs(data *godog.DocString) ([]bsoncore.Document, error) {
 	if data == nil {
 		return nil, errors.New("data is nil") // nolint: goerr113
 	}
 
-	return bytesToDocs([]byte(data.Content))
+	if isNDJSONMediaType(data.MediaType) {
+		return readAllDocs(newNDJSONReader(strings.NewReader(data.Content), nil))
+	}
+
+	var docs []bsoncore.Document
+
+	if err := unmarshalDocString(data, &docs); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// unmarshalDocString unmarshals the content of the DocString, which is ExtJSON, or YAML when the media type is yaml.
+func unmarshalDocString(data *godog.DocString, v interface{}) error {
+	content := []byte(data.Content)
+
+	if isYAMLMediaType(data.MediaType) {
+		var err error
+
+		if content, err = yamlToExtJSON(content); err != nil {
+			return err
+		}
+	}
+
+	if err := bson.UnmarshalExtJSON(content, true, v); err != nil {
+		return fmt.Errorf("error unmarshaling extjson: %w", err)
+	}
+
+	return nil
+}
+
+const (
+	mediaTypeNDJSON = "ndjson"
+
+	// docsFileHeadSize is the size of the beginning of a file that is used to detect its format.
+	docsFileHeadSize = 64 * 1024
+)
+
+// docsReader reads documents one by one, it returns io.EOF when there are no more documents.
+type docsReader interface {
+	Next() (bsoncore.Document, error)
+	Close() error
+}
+
+// docsSliceReader reads documents that are already in memory.
+type docsSliceReader struct {
+	docs []bsoncore.Document
+}
+
+func (r *docsSliceReader) Next() (bsoncore.Document, error) {
+	if len(r.docs) == 0 {
+		return nil, io.EOF
+	}
+
+	doc := r.docs[0]
+	r.docs = r.docs[1:]
+
+	return doc, nil
+}
+
+func (r *docsSliceReader) Close() error {
+	return nil
+}
+
+// ndjsonReader reads ExtJSON documents, one per line, as written by mongoexport. The blank lines are skipped.
+type ndjsonReader struct {
+	r       *bufio.Reader
+	closer  io.Closer
+	resolve docStringResolver
+	line    int
+}
+
+func (r *ndjsonReader) Next() (bsoncore.Document, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if len(line) > 0 {
+			r.line++
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			if r.resolve != nil {
+				data, err := r.resolve(&godog.DocString{Content: string(line)})
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", r.line, err)
+				}
+
+				line = []byte(data.Content)
+			}
+
+			var doc bsoncore.Document
+
+			if err := bson.UnmarshalExtJSON(line, false, &doc); err != nil {
+				return nil, fmt.Errorf("line %d: error unmarshaling extjson: %w", r.line, err)
+			}
+
+			return doc, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *ndjsonReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+
+	return r.closer.Close()
+}
+
+func newNDJSONReader(r io.Reader, closer io.Closer) *ndjsonReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &ndjsonReader{r: br, closer: closer}
+}
+
+// readAllDocs reads all the documents from the reader.
+func readAllDocs(r docsReader) ([]bsoncore.Document, error) {
+	docs := make([]bsoncore.Document, 0)
+
+	for {
+		doc, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		docs = append(docs, doc)
+	}
+}
+
+// openDocsFile opens a file of documents. The NDJSON files are read one document at a time, the other formats are read
+// at once. The content is resolved before it is parsed, unless the resolver is nil.
+func openDocsFile(filePath string, resolve docStringResolver) (docsReader, error) {
+	f, err := os.Open(path.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReaderSize(f, docsFileHeadSize)
+	mediaType := docsFileMediaType(filePath)
+
+	// A JSON file whose first line is a whole document, like the output of mongoexport, is streamed as NDJSON.
+	if mediaType == "" {
+		head, _ := r.Peek(docsFileHeadSize) // nolint: errcheck
+
+		if startsWithDocumentLine(head) {
+			mediaType = mediaTypeNDJSON
+		}
+	}
+
+	if mediaType == mediaTypeNDJSON {
+		reader := newNDJSONReader(r, f)
+		reader.resolve = resolve
+
+		return reader, nil
+	}
+
+	defer f.Close() // nolint: errcheck
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	content := fileContentToDocString(filePath, data)
+
+	if resolve != nil {
+		if content, err = resolve(content); err != nil {
+			return nil, err
+		}
+	}
+
+	docs, err := stringToDocs(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &docsSliceReader{docs: docs}, nil
+}
+
+// fileToDocString reads a file of documents as a DocString of its media type.
+func fileToDocString(filePath string) (*godog.DocString, error) {
+	data, err := os.ReadFile(path.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	return fileContentToDocString(filePath, data), nil
+}
+
+// fileContentToDocString returns the content of a file of documents as a DocString of its media type. A JSON file that
+// holds one document instead of an array is read as an array of this document, and a JSON file that holds several
+// documents, like the output of mongoexport, is read as NDJSON.
+func fileContentToDocString(filePath string, data []byte) *godog.DocString {
+	mediaType := docsFileMediaType(filePath)
+
+	if trimmed := bytes.TrimSpace(data); mediaType == "" && len(trimmed) > 0 && trimmed[0] == '{' {
+		if json.Valid(trimmed) {
+			return &godog.DocString{Content: "[" + string(trimmed) + "]"}
+		}
+
+		mediaType = mediaTypeNDJSON
+	}
+
+	return &godog.DocString{MediaType: mediaType, Content: string(data)}
+}
+
+// startsWithDocumentLine checks whether the first line of the content is a whole JSON document.
+func startsWithDocumentLine(head []byte) bool {
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i]
+	}
+
+	head = bytes.TrimSpace(head)
+
+	return len(head) > 0 && head[0] == '{' && json.Valid(head)
+}
+
+// docsFileMediaType detects the media type of a file of documents by its extension.
+func docsFileMediaType(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return mediaTypeYAML
+
+	case ".ndjson", ".jsonl":
+		return mediaTypeNDJSON
+	}
+
+	return ""
+}
+
+// isNDJSONMediaType checks whether the media type of a DocString is NDJSON.
+func isNDJSONMediaType(mediaType string) bool {
+	switch strings.ToLower(mediaType) {
+	case "ndjson", "jsonl":
+		return true
+	default:
+		return false
+	}
 }
 
 func bytesToDocs(data []byte) ([]bsoncore.Document, error) {
@@ -29,6 +302,189 @@ func bytesToDocs(data []byte) ([]bsoncore.Document, error) {
 	return docs, nil
 }
 
+func stringToPipeline(data *godog.DocString) (mongo.Pipeline, error) {
+	if data == nil {
+		return nil, errors.New("data is nil") // nolint: goerr113
+	}
+
+	var pipeline mongo.Pipeline
+
+	if err := unmarshalDocString(data, &pipeline); err != nil {
+		return nil, err
+	}
+
+	return pipeline, nil
+}
+
+// stringToIndexes parses the indexes in the format of the createIndexes command, an index without a name gets the name
+// that the server would generate from its key.
+func stringToIndexes(data *godog.DocString) ([]bson.D, error) {
+	if data == nil {
+		return nil, errors.New("data is nil") // nolint: goerr113
+	}
+
+	var indexes []bson.D
+
+	if err := unmarshalDocString(data, &indexes); err != nil {
+		return nil, err
+	}
+
+	for i, index := range indexes {
+		key, hasName := bson.D(nil), false
+
+		for _, e := range index {
+			switch e.Key {
+			case "key":
+				key, _ = e.Value.(bson.D) // nolint: errcheck
+			case "name":
+				hasName = true
+			}
+		}
+
+		if len(key) == 0 {
+			return nil, fmt.Errorf("index #%d has no key", i+1) // nolint: goerr113
+		}
+
+		if !hasName {
+			indexes[i] = append(index, bson.E{Key: "name", Value: indexName(key)})
+		}
+	}
+
+	return indexes, nil
+}
+
+// indexName generates the default name of an index from its key, for example {"email": 1, "age": -1} is
+// "email_1_age_-1".
+func indexName(key bson.D) string {
+	parts := make([]string, 0, len(key)*2)
+
+	for _, e := range key {
+		parts = append(parts, e.Key, fmt.Sprint(e.Value))
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// withoutFields returns a copy of the document without the given top-level fields.
+func withoutFields(doc bsoncore.Document, keys ...string) (bsoncore.Document, error) {
+	elems, err := doc.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	idx, result := bsoncore.AppendDocumentStart(nil)
+
+	for _, e := range elems {
+		if !containsString(keys, e.Key()) {
+			result = append(result, e...)
+		}
+	}
+
+	return bsoncore.AppendDocumentEnd(result, idx)
+}
+
+// withoutFieldPaths returns the document without the fields at the dotted paths, like "address.city". The nested paths
+// of an array, like "items.price", apply to each embedded document of the array.
+func withoutFieldPaths(doc bsoncore.Document, paths ...string) (bsoncore.Document, error) {
+	elems, err := doc.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	idx, result := bsoncore.AppendDocumentStart(nil)
+
+	for _, e := range elems {
+		if containsString(paths, e.Key()) {
+			continue
+		}
+
+		var nested []string
+
+		for _, p := range paths {
+			if strings.HasPrefix(p, e.Key()+".") {
+				nested = append(nested, strings.TrimPrefix(p, e.Key()+"."))
+			}
+		}
+
+		switch {
+		case len(nested) == 0:
+			result = append(result, e...)
+
+		case e.Value().Type == bsontype.EmbeddedDocument:
+			sub, err := withoutFieldPaths(e.Value().Document(), nested...)
+			if err != nil {
+				return nil, err
+			}
+
+			result = bsoncore.AppendDocumentElement(result, e.Key(), sub)
+
+		case e.Value().Type == bsontype.Array:
+			sub, err := arrayWithoutFieldPaths(e.Value().Array(), nested...)
+			if err != nil {
+				return nil, err
+			}
+
+			result = bsoncore.AppendArrayElement(result, e.Key(), sub)
+
+		default:
+			result = append(result, e...)
+		}
+	}
+
+	return bsoncore.AppendDocumentEnd(result, idx)
+}
+
+// arrayWithoutFieldPaths returns the array without the fields at the dotted paths in each of its embedded documents.
+func arrayWithoutFieldPaths(arr bsoncore.Array, paths ...string) (bsoncore.Array, error) {
+	values, err := arr.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	idx, result := bsoncore.AppendArrayStart(nil)
+
+	for i, v := range values {
+		if v.Type == bsontype.EmbeddedDocument {
+			sub, err := withoutFieldPaths(v.Document(), paths...)
+			if err != nil {
+				return nil, err
+			}
+
+			v = bsoncore.Value{Type: bsontype.EmbeddedDocument, Data: sub}
+		}
+
+		result = bsoncore.AppendValueElement(result, strconv.Itoa(i), v)
+	}
+
+	return bsoncore.AppendArrayEnd(result, idx)
+}
+
+// docsWithoutFieldPaths returns the documents without the fields at the dotted paths.
+func docsWithoutFieldPaths(docs []bsoncore.Document, paths ...string) ([]bsoncore.Document, error) {
+	result := make([]bsoncore.Document, len(docs))
+
+	for i, doc := range docs {
+		d, err := withoutFieldPaths(doc, paths...)
+		if err != nil {
+			return nil, fmt.Errorf("document #%d: %w", i+1, err)
+		}
+
+		result[i] = d
+	}
+
+	return result, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
 func docsToExtJSON(docs []bsoncore.Document) ([]byte, error) {
 	if len(docs) == 0 {
 		return []byte("[]"), nil
@@ -55,7 +511,13 @@ func stringToBSOND(data *godog.DocString) (bson.D, error) {
 		return bson.D{}, nil
 	}
 
-	return bytesToBSOND([]byte(data.Content))
+	var result bson.D
+
+	if err := unmarshalDocString(data, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 func bytesToBSOND(data []byte) (bson.D, error) {
@@ -67,3 +529,382 @@ func bytesToBSOND(data []byte) (bson.D, error) {
 
 	return result, nil
 }
+
+// updateQuery is an update to apply to the documents matching the filter.
+type updateQuery struct {
+	Filter bson.D      `bson:"filter"`
+	Update interface{} `bson:"update"`
+}
+
+func stringToUpdateQuery(data *godog.DocString) (updateQuery, error) {
+	if data == nil {
+		return updateQuery{}, errors.New("data is nil") // nolint: goerr113
+	}
+
+	doc, err := stringToBSOND(data)
+	if err != nil {
+		return updateQuery{}, err
+	}
+
+	for _, e := range doc {
+		switch e.Key {
+		case "filter", "update":
+		default:
+			return updateQuery{}, fmt.Errorf("unsupported update key %q", e.Key) // nolint: goerr113
+		}
+	}
+
+	var q updateQuery
+
+	if err := unmarshalDocString(data, &q); err != nil {
+		return updateQuery{}, err
+	}
+
+	if q.Update == nil {
+		return updateQuery{}, errors.New("update is missing") // nolint: goerr113
+	}
+
+	// An update of all the documents needs an explicit empty filter.
+	if q.Filter == nil {
+		return updateQuery{}, errors.New("filter is missing") // nolint: goerr113
+	}
+
+	return q, nil
+}
+
+// findQuery is a filter with the options for finding documents.
+type findQuery struct {
+	Filter     bson.D      `bson:"filter"`
+	Sort       bson.D      `bson:"sort"`
+	Projection bson.D      `bson:"projection"`
+	Limit      int64       `bson:"limit"`
+	Skip       int64       `bson:"skip"`
+	Collation  *collation  `bson:"collation"`
+	Hint       interface{} `bson:"hint"`
+}
+
+// collation mirrors options.Collation with the field names used by the server.
+type collation struct {
+	Locale          string `bson:"locale"`
+	CaseLevel       bool   `bson:"caseLevel"`
+	CaseFirst       string `bson:"caseFirst"`
+	Strength        int    `bson:"strength"`
+	NumericOrdering bool   `bson:"numericOrdering"`
+	Alternate       string `bson:"alternate"`
+	MaxVariable     string `bson:"maxVariable"`
+	Normalization   bool   `bson:"normalization"`
+	Backwards       bool   `bson:"backwards"`
+}
+
+// findOptions returns the options for finding documents, the documents are sorted by _id unless a sort is provided.
+func (q findQuery) findOptions() *options.FindOptions {
+	opts := options.Find().SetLimit(q.Limit).SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	if q.Sort != nil {
+		opts.SetSort(q.Sort)
+	}
+
+	if q.Projection != nil {
+		opts.SetProjection(q.Projection)
+	}
+
+	if q.Skip > 0 {
+		opts.SetSkip(q.Skip)
+	}
+
+	if q.Collation != nil {
+		opts.SetCollation((*options.Collation)(q.Collation))
+	}
+
+	if q.Hint != nil {
+		opts.SetHint(q.Hint)
+	}
+
+	return opts
+}
+
+// stringToFindQuery parses either a plain filter or a query with a filter and find options.
+func stringToFindQuery(data *godog.DocString) (findQuery, error) {
+	filter, err := stringToBSOND(data)
+	if err != nil {
+		return findQuery{}, err
+	}
+
+	if !isFindQuery(filter) {
+		return findQuery{Filter: filter}, nil
+	}
+
+	var q findQuery
+
+	if err := unmarshalDocString(data, &q); err != nil {
+		return findQuery{}, err
+	}
+
+	if q.Filter == nil {
+		q.Filter = bson.D{}
+	}
+
+	return q, nil
+}
+
+// isFindQuery checks whether the document has a filter document, or a null one, and only the keys of a find query.
+func isFindQuery(doc bson.D) bool {
+	hasFilter := false
+
+	for _, e := range doc {
+		switch e.Key {
+		case "filter":
+			if _, ok := e.Value.(bson.D); !ok && e.Value != nil {
+				return false
+			}
+
+			hasFilter = true
+		case "sort", "projection", "limit", "skip", "collation", "hint":
+		default:
+			return false
+		}
+	}
+
+	return hasFilter
+}
+
+// collectionOptions is the subset of the create command options that are supported when creating a collection.
+type collectionOptions struct {
+	Capped             *bool       `bson:"capped"`
+	Size               *int64      `bson:"size"`
+	Max                *int64      `bson:"max"`
+	Collation          *collation  `bson:"collation"`
+	Validator          interface{} `bson:"validator"`
+	ValidationLevel    *string     `bson:"validationLevel"`
+	ValidationAction   *string     `bson:"validationAction"`
+	ExpireAfterSeconds *int64      `bson:"expireAfterSeconds"`
+	ClusteredIndex     interface{} `bson:"clusteredIndex"`
+}
+
+// createCollectionOptions returns the options for creating a collection.
+func (o collectionOptions) createCollectionOptions() *options.CreateCollectionOptions {
+	opts := options.CreateCollection()
+
+	if o.Capped != nil {
+		opts.SetCapped(*o.Capped)
+	}
+
+	if o.Size != nil {
+		opts.SetSizeInBytes(*o.Size)
+	}
+
+	if o.Max != nil {
+		opts.SetMaxDocuments(*o.Max)
+	}
+
+	if o.Collation != nil {
+		opts.SetCollation((*options.Collation)(o.Collation))
+	}
+
+	if o.Validator != nil {
+		opts.SetValidator(o.Validator)
+	}
+
+	if o.ValidationLevel != nil {
+		opts.SetValidationLevel(*o.ValidationLevel)
+	}
+
+	if o.ValidationAction != nil {
+		opts.SetValidationAction(*o.ValidationAction)
+	}
+
+	if o.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*o.ExpireAfterSeconds)
+	}
+
+	if o.ClusteredIndex != nil {
+		opts.SetClusteredIndex(o.ClusteredIndex)
+	}
+
+	return opts
+}
+
+// stringToCollectionOptions parses the options for creating a collection, no data means no options.
+func stringToCollectionOptions(data *godog.DocString) (collectionOptions, error) {
+	if data == nil {
+		return collectionOptions{}, nil
+	}
+
+	doc, err := stringToBSOND(data)
+	if err != nil {
+		return collectionOptions{}, err
+	}
+
+	for _, e := range doc {
+		switch e.Key {
+		case "capped", "size", "max", "collation", "validator", "validationLevel", "validationAction",
+			"expireAfterSeconds", "clusteredIndex":
+		default:
+			return collectionOptions{}, fmt.Errorf("unsupported collection option %q", e.Key) // nolint: goerr113
+		}
+	}
+
+	var o collectionOptions
+
+	if err := unmarshalDocString(data, &o); err != nil {
+		return collectionOptions{}, err
+	}
+
+	return o, nil
+}
+
+// validation is a collection validator with its level and action.
+type validation struct {
+	Validator        bson.D `bson:"validator"`
+	ValidationLevel  string `bson:"validationLevel,omitempty"`
+	ValidationAction string `bson:"validationAction,omitempty"`
+}
+
+// stringToValidation parses either a plain validator or a validator with its level and action.
+func stringToValidation(data *godog.DocString) (validation, error) {
+	if data == nil {
+		return validation{}, errors.New("data is nil") // nolint: goerr113
+	}
+
+	doc, err := stringToBSOND(data)
+	if err != nil {
+		return validation{}, err
+	}
+
+	if !isValidation(doc) {
+		return validation{Validator: doc}, nil
+	}
+
+	var v validation
+
+	if err := unmarshalDocString(data, &v); err != nil {
+		return validation{}, err
+	}
+
+	return v, nil
+}
+
+// isValidation checks whether the document has a validator and only the keys of a validation.
+func isValidation(doc bson.D) bool {
+	hasValidator := false
+
+	for _, e := range doc {
+		switch e.Key {
+		case "validator":
+			hasValidator = true
+		case "validationLevel", "validationAction":
+		default:
+			return false
+		}
+	}
+
+	return hasValidator
+}
+
+// generateValues replaces the generator expressions in the ExtJSON with generated values:
+//
+//   - {"$now": {}} is the current date, and {"$now": "-24h"} is the current date moved by the duration.
+//   - {"$newOid": {}} is a new ObjectID.
+//   - {"$uuid": {}} is a new UUID, as a binary of subtype 4. A {"$uuid": "..."} with a UUID is left as is.
+//
+// The values of {"$newOid": "alias"} and {"$uuid": "alias"} are generated once for the alias, they are kept with get
+// and set so that they can be used again. It returns false when there is no generator expression.
+func generateValues(content string, get func(alias string) (interface{}, bool), set func(alias string, v interface{})) (string, bool, error) {
+	var err error
+
+	generated := false
+
+	result := generatorPattern.ReplaceAllStringFunc(content, func(expr string) string {
+		if err != nil {
+			return expr
+		}
+
+		match := generatorPattern.FindStringSubmatch(expr)
+		generator, arg := match[1], strings.Trim(match[2], `"`)
+
+		if strings.HasPrefix(match[2], "{") {
+			arg = ""
+		}
+
+		var v interface{}
+
+		switch {
+		case generator == "now":
+			v, err = generateNow(arg)
+
+		case arg == "":
+			v, err = generateValue(generator)
+
+		case !aliasPattern.MatchString(arg):
+			if generator == "uuid" {
+				return expr
+			}
+
+			err = fmt.Errorf("invalid alias %q of $%s", arg, generator) // nolint: goerr113
+
+		default:
+			var ok bool
+
+			if v, ok = get(arg); !ok {
+				if v, err = generateValue(generator); err == nil {
+					set(arg, v)
+				}
+			}
+		}
+
+		if err != nil {
+			return expr
+		}
+
+		data, e := valueToExtJSON(v)
+		if e != nil {
+			err = e
+
+			return expr
+		}
+
+		generated = true
+
+		return data
+	})
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return result, generated, nil
+}
+
+// generateNow returns the current date, moved by the duration when there is one.
+func generateNow(offset string) (primitive.DateTime, error) {
+	now := time.Now()
+
+	if offset == "" {
+		return primitive.NewDateTimeFromTime(now), nil
+	}
+
+	d, err := time.ParseDuration(offset)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration of $now: %w", err)
+	}
+
+	return primitive.NewDateTimeFromTime(now.Add(d)), nil
+}
+
+// generateValue returns a new ObjectID for $newOid, or a new random UUID for $uuid.
+func generateValue(generator string) (interface{}, error) {
+	if generator == "newOid" {
+		return primitive.NewObjectID(), nil
+	}
+
+	var uuid [16]byte
+
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return nil, fmt.Errorf("could not generate uuid: %w", err)
+	}
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x40 // Version 4.
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // Variant RFC 4122.
+
+	return primitive.Binary{Subtype: bson.TypeBinaryUUID, Data: uuid[:]}, nil
+}
@@ -0,0 +1,292 @@
+package mongosteps
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestNDJSONReader(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		data          string
+		expected      string
+		expectedError string
+	}{
+		{
+			scenario: "empty",
+			expected: `[]`,
+		},
+		{
+			scenario: "relaxed extjson",
+			data: `{"_id":{"$oid":"6250053966df8910f804c3a7"},"created":{"$date":"2022-04-08T10:00:00Z"},"age":30}
+{"_id":{"$oid":"6250053966df8910f804c3a8"},"score":4.5}`,
+			expected: `[
+				{"_id": {"$oid": "6250053966df8910f804c3a7"}, "created": {"$date": {"$numberLong": "1649412000000"}}, "age": {"$numberInt": "30"}},
+				{"_id": {"$oid": "6250053966df8910f804c3a8"}, "score": {"$numberDouble": "4.5"}}
+			]`,
+		},
+		{
+			scenario: "blank lines",
+			data:     "\n{\"name\":\"John Doe\"}\r\n\n  \n{\"name\":\"Jane Doe\"}\n",
+			expected: `[{"name": "John Doe"}, {"name": "Jane Doe"}]`,
+		},
+		{
+			scenario:      "malformed line",
+			data:          "{\"name\":\"John Doe\"}\n\n{\"name\":",
+			expectedError: `line 3: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			docs, err := readAllDocs(newNDJSONReader(strings.NewReader(tc.data), nil))
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			actual, err := docsToExtJSON(docs)
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestFileContentToDocString(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario          string
+		filePath          string
+		content           string
+		expected          string
+		expectedMediaType string
+	}{
+		{
+			scenario: "json array",
+			filePath: "customers.json",
+			content:  "\n  [{\"name\": \"John Doe\"}]",
+			expected: "\n  [{\"name\": \"John Doe\"}]",
+		},
+		{
+			scenario: "json document",
+			filePath: "customers.json",
+			content:  "{\n  \"name\": \"John Doe\",\n  \"age\": 30\n}\n",
+			expected: "[{\n  \"name\": \"John Doe\",\n  \"age\": 30\n}]",
+		},
+		{
+			scenario:          "mongoexport",
+			filePath:          "customers.json",
+			content:           "{\"name\": \"John Doe\"}\n{\"name\": \"Jane Doe\"}\n",
+			expected:          "{\"name\": \"John Doe\"}\n{\"name\": \"Jane Doe\"}\n",
+			expectedMediaType: mediaTypeNDJSON,
+		},
+		{
+			scenario:          "ndjson",
+			filePath:          "customers.ndjson",
+			content:           "{\"name\": \"John Doe\"}\n",
+			expected:          "{\"name\": \"John Doe\"}\n",
+			expectedMediaType: mediaTypeNDJSON,
+		},
+		{
+			scenario:          "json lines",
+			filePath:          "customers.JSONL",
+			expectedMediaType: mediaTypeNDJSON,
+		},
+		{
+			scenario:          "yaml",
+			filePath:          "customers.yml",
+			content:           "{name: John Doe}",
+			expected:          "{name: John Doe}",
+			expectedMediaType: mediaTypeYAML,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			actual := fileContentToDocString(tc.filePath, []byte(tc.content))
+
+			assert.Equal(t, tc.expectedMediaType, actual.MediaType)
+			assert.Equal(t, tc.expected, actual.Content)
+		})
+	}
+}
+
+func TestOpenDocsFile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		fileName string
+		content  string
+		streamed bool
+		expected string
+	}{
+		{
+			scenario: "json array",
+			fileName: "customers.json",
+			content:  "[\n  {\"name\": \"John Doe\"},\n  {\"name\": \"Jane Doe\"}\n]\n",
+			expected: `[{"name": "John Doe"}, {"name": "Jane Doe"}]`,
+		},
+		{
+			scenario: "json document",
+			fileName: "customers.json",
+			content:  "{\n  \"name\": \"John Doe\"\n}\n",
+			expected: `[{"name": "John Doe"}]`,
+		},
+		{
+			scenario: "mongoexport",
+			fileName: "customers.json",
+			content:  "{\"name\": \"John Doe\"}\n{\"name\": \"Jane Doe\"}\n",
+			streamed: true,
+			expected: `[{"name": "John Doe"}, {"name": "Jane Doe"}]`,
+		},
+		{
+			scenario: "ndjson",
+			fileName: "customers.ndjson",
+			content:  "{\"name\": \"John Doe\"}\n",
+			streamed: true,
+			expected: `[{"name": "John Doe"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			filePath := filepath.Join(t.TempDir(), tc.fileName)
+
+			err := os.WriteFile(filePath, []byte(tc.content), 0o600)
+			assert.NoError(t, err)
+
+			r, err := openDocsFile(filePath, nil)
+			assert.NoError(t, err)
+
+			defer r.Close() // nolint: errcheck
+
+			_, streamed := r.(*ndjsonReader)
+			assert.Equal(t, tc.streamed, streamed)
+
+			docs, err := readAllDocs(r)
+			assert.NoError(t, err)
+
+			actual, err := docsToExtJSON(docs)
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestDocsSliceReader(t *testing.T) {
+	t.Parallel()
+
+	docs := []bsoncore.Document{
+		bsoncore.NewDocumentBuilder().AppendString("name", "John Doe").Build(),
+		bsoncore.NewDocumentBuilder().AppendString("name", "Jane Doe").Build(),
+	}
+
+	r := &docsSliceReader{docs: docs}
+
+	for _, expected := range docs {
+		doc, err := r.Next()
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, doc)
+	}
+
+	_, err := r.Next()
+
+	assert.True(t, errors.Is(err, io.EOF))
+	assert.NoError(t, r.Close())
+}
+
+func TestGenerateValues(t *testing.T) {
+	t.Parallel()
+
+	aliases := map[string]interface{}{}
+	get := func(alias string) (interface{}, bool) {
+		v, ok := aliases[alias]
+
+		return v, ok
+	}
+	set := func(alias string, v interface{}) {
+		aliases[alias] = v
+	}
+
+	before := time.Now().Add(-24 * time.Hour).Truncate(time.Millisecond)
+
+	content, generated, err := generateValues(`[
+		{"_id": {"$newOid": "order"}, "ref": {"$uuid": "order_ref"}, "created": {"$now": "-24h"}, "updated": {"$now": {}}},
+		{"_id": {"$newOid": {}}, "order": {"$newOid": "order"}, "ref": {"$uuid": "order_ref"}, "key": {"$uuid": "7f8d1c0e-3b5a-4c2e-9f1d-2a6b8c4e0d13"}}
+	]`, get, set)
+	assert.NoError(t, err)
+	assert.True(t, generated)
+
+	after := time.Now()
+
+	var docs []bson.D
+
+	if !assert.NoError(t, bson.UnmarshalExtJSON([]byte(content), true, &docs)) {
+		return
+	}
+
+	first, second := docs[0].Map(), docs[1].Map()
+
+	assert.Equal(t, aliases["order"], first["_id"])
+	assert.Equal(t, aliases["order"], second["order"])
+	assert.NotEqual(t, first["_id"], second["_id"])
+	assert.IsType(t, primitive.ObjectID{}, second["_id"])
+
+	assert.Equal(t, aliases["order_ref"], first["ref"])
+	assert.Equal(t, aliases["order_ref"], second["ref"])
+	assert.Equal(t, bson.TypeBinaryUUID, first["ref"].(primitive.Binary).Subtype) // nolint: forcetypeassert
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, valueToText(first["ref"]))
+	assert.Equal(t, "7f8d1c0e-3b5a-4c2e-9f1d-2a6b8c4e0d13", valueToText(second["key"]))
+
+	created := first["created"].(primitive.DateTime).Time() // nolint: forcetypeassert
+	updated := first["updated"].(primitive.DateTime).Time() // nolint: forcetypeassert
+
+	assert.False(t, created.Before(before))
+	assert.False(t, created.After(after.Add(-24*time.Hour)))
+	assert.False(t, updated.Before(before.Add(24*time.Hour)))
+	assert.False(t, updated.After(after))
+
+	content, generated, err = generateValues(`{"name": "John Doe", "$set": {"now": "{\"$now\": {}}"}}`, get, set)
+	assert.NoError(t, err)
+	assert.False(t, generated)
+	assert.Equal(t, `{"name": "John Doe", "$set": {"now": "{\"$now\": {}}"}}`, content)
+
+	_, _, err = generateValues(`{"created": {"$now": "yesterday"}}`, get, set)
+	assert.EqualError(t, err, `invalid duration of $now: time: invalid duration "yesterday"`)
+
+	_, _, err = generateValues(`{"_id": {"$newOid": "my order"}}`, get, set)
+	assert.EqualError(t, err, `invalid alias "my order" of $newOid`)
+}
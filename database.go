@@ -2,7 +2,11 @@ package mongosteps
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -10,6 +14,9 @@ import (
 	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 )
 
+// storeBatchSize is the number of documents that are inserted at once when storing documents from a reader.
+const storeBatchSize = 1000
+
 // DatabaseOption sets the database option.
 type DatabaseOption interface {
 	applyDatabaseOption(d *database)
@@ -24,16 +31,27 @@ func (f databaseOptionFunc) applyDatabaseOption(d *database) {
 type database struct {
 	conn     *mongo.Database
 	cleanUps []string
+	anyOrder bool
 }
 
-// cleanUp cleans up the collections in the database.
-func (d *database) cleanUp(ctx context.Context) error {
+// cleanUp cleans up the collections in the database, and the other collections that are registered in the scenario.
+func (d *database) cleanUp(ctx context.Context, collections ...string) error {
 	for _, collection := range d.cleanUps {
 		if err := d.truncate(ctx, collection); err != nil {
 			return err
 		}
 	}
 
+	for _, collection := range collections {
+		if containsString(d.cleanUps, collection) {
+			continue
+		}
+
+		if err := d.truncate(ctx, collection); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -59,6 +77,28 @@ func (d *database) find(ctx context.Context, collection string, filter interface
 	return result, nil
 }
 
+// aggregate runs the aggregation pipeline on the collection and returns the resulting documents.
+func (d *database) aggregate(ctx context.Context, collection string, pipeline interface{}) ([]bsoncore.Document, error) {
+	cursor, err := d.conn.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("could not run aggregation on collection %q: %w", collection, err)
+	}
+
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	var result []bsoncore.Document
+
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("could not read aggregation result from collection %q: %w", collection, err)
+	}
+
+	if len(result) == 0 {
+		return []bsoncore.Document{}, nil
+	}
+
+	return result, nil
+}
+
 // truncate deletes all the documents the collection.
 func (d *database) truncate(ctx context.Context, collection string) error {
 	if _, err := d.conn.Collection(collection).DeleteMany(ctx, bson.D{}); err != nil {
@@ -68,6 +108,16 @@ func (d *database) truncate(ctx context.Context, collection string) error {
 	return nil
 }
 
+// delete deletes the documents in the collection that match the filter.
+func (d *database) delete(ctx context.Context, collection string, filter interface{}) (int64, error) {
+	result, err := d.conn.Collection(collection).DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("could not delete documents from collection %q: %w", collection, err)
+	}
+
+	return result.DeletedCount, nil
+}
+
 func (d *database) store(ctx context.Context, collection string, docs []bsoncore.Document) error {
 	documents := make([]interface{}, len(docs))
 	for i, doc := range docs {
@@ -81,6 +131,115 @@ func (d *database) store(ctx context.Context, collection string, docs []bsoncore
 	return nil
 }
 
+// storeAll stores the documents from the reader in batches, so that they are not all in memory at once. A reader without
+// documents is an error, as in store, unless empty is allowed.
+func (d *database) storeAll(ctx context.Context, collection string, r docsReader, allowEmpty bool) error {
+	batch := make([]bsoncore.Document, 0, storeBatchSize)
+	stored := 0
+
+	for {
+		doc, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not read documents for collection %q: %w", collection, err)
+		}
+
+		if batch = append(batch, doc); len(batch) < storeBatchSize {
+			continue
+		}
+
+		if err := d.store(ctx, collection, batch); err != nil {
+			return err
+		}
+
+		stored += len(batch)
+		batch = batch[:0]
+	}
+
+	if len(batch) > 0 || (stored == 0 && !allowEmpty) {
+		return d.store(ctx, collection, batch)
+	}
+
+	return nil
+}
+
+// update updates the documents in the collection that match the filter.
+func (d *database) update(ctx context.Context, collection string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	result, err := d.conn.Collection(collection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return nil, fmt.Errorf("could not update documents in collection %q: %w", collection, err)
+	}
+
+	return result, nil
+}
+
+// upsert updates the documents in the collection that match the filter, or inserts a new one if there is no match.
+func (d *database) upsert(ctx context.Context, collection string, filter, update interface{}) (*mongo.UpdateResult, error) {
+	result, err := d.conn.Collection(collection).UpdateMany(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("could not upsert documents in collection %q: %w", collection, err)
+	}
+
+	return result, nil
+}
+
+// createIndexes creates the indexes in the collection. The indexes are in the format of the createIndexes command.
+func (d *database) createIndexes(ctx context.Context, collection string, indexes []bson.D) error {
+	cmd := bson.D{
+		{Key: "createIndexes", Value: collection},
+		{Key: "indexes", Value: indexes},
+	}
+
+	if err := d.conn.RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("could not create indexes in collection %q: %w", collection, err)
+	}
+
+	return nil
+}
+
+// dropIndex drops the index from the collection.
+func (d *database) dropIndex(ctx context.Context, collection, name string) error {
+	if _, err := d.conn.Collection(collection).Indexes().DropOne(ctx, name); err != nil {
+		return fmt.Errorf("could not drop index %q from collection %q: %w", name, collection, err)
+	}
+
+	return nil
+}
+
+// dropIndexes drops all the indexes, except the one on _id, from the collection.
+func (d *database) dropIndexes(ctx context.Context, collection string) error {
+	if _, err := d.conn.Collection(collection).Indexes().DropAll(ctx); err != nil {
+		return fmt.Errorf("could not drop indexes from collection %q: %w", collection, err)
+	}
+
+	return nil
+}
+
+// listIndexes returns the indexes of the collection.
+func (d *database) listIndexes(ctx context.Context, collection string) ([]bsoncore.Document, error) {
+	cursor, err := d.conn.Collection(collection).Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list indexes of collection %q: %w", collection, err)
+	}
+
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	var result []bsoncore.Document
+
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, fmt.Errorf("could not read indexes of collection %q: %w", collection, err)
+	}
+
+	if len(result) == 0 {
+		return []bsoncore.Document{}, nil
+	}
+
+	return result, nil
+}
+
 func (d *database) count(ctx context.Context, collection string, filter interface{}) (int64, error) {
 	count, err := d.conn.Collection(collection).CountDocuments(ctx, filter)
 	if err != nil {
@@ -90,6 +249,102 @@ func (d *database) count(ctx context.Context, collection string, filter interfac
 	return count, nil
 }
 
+// createCollection creates the collection with the options.
+func (d *database) createCollection(ctx context.Context, collection string, opts *options.CreateCollectionOptions) error {
+	if err := d.conn.CreateCollection(ctx, collection, opts); err != nil {
+		return fmt.Errorf("could not create collection %q: %w", collection, err)
+	}
+
+	return nil
+}
+
+// createCollectionWithCommandOptions creates the collection with the options of the create command, as they are in a
+// dump.
+func (d *database) createCollectionWithCommandOptions(ctx context.Context, collection string, opts bson.D) error {
+	cmd := append(bson.D{{Key: "create", Value: collection}}, opts...)
+
+	if err := d.conn.RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("could not create collection %q: %w", collection, err)
+	}
+
+	return nil
+}
+
+// dropCollection drops the collection.
+func (d *database) dropCollection(ctx context.Context, collection string) error {
+	if err := d.conn.Collection(collection).Drop(ctx); err != nil {
+		return fmt.Errorf("could not drop collection %q: %w", collection, err)
+	}
+
+	return nil
+}
+
+// renameCollection renames the collection within the database.
+func (d *database) renameCollection(ctx context.Context, collection, name string) error {
+	cmd := bson.D{
+		{Key: "renameCollection", Value: d.conn.Name() + "." + collection},
+		{Key: "to", Value: d.conn.Name() + "." + name},
+	}
+
+	if err := d.conn.Client().Database("admin").RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("could not rename collection %q to %q: %w", collection, name, err)
+	}
+
+	return nil
+}
+
+// applyValidation sets the validator of the collection.
+func (d *database) applyValidation(ctx context.Context, collection string, v validation) error {
+	cmd := bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: v.Validator},
+	}
+
+	if v.ValidationLevel != "" {
+		cmd = append(cmd, bson.E{Key: "validationLevel", Value: v.ValidationLevel})
+	}
+
+	if v.ValidationAction != "" {
+		cmd = append(cmd, bson.E{Key: "validationAction", Value: v.ValidationAction})
+	}
+
+	if err := d.conn.RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("could not apply validator to collection %q: %w", collection, err)
+	}
+
+	return nil
+}
+
+// hasCollection checks whether the collection exists in the database.
+func (d *database) hasCollection(ctx context.Context, collection string) (bool, error) {
+	names, err := d.conn.ListCollectionNames(ctx, bson.D{{Key: "name", Value: collection}})
+	if err != nil {
+		return false, fmt.Errorf("could not list collections: %w", err)
+	}
+
+	return containsString(names, collection), nil
+}
+
+// listCollections returns the names of the collections in the database, except the system collections, by name.
+func (d *database) listCollections(ctx context.Context) ([]string, error) {
+	names, err := d.conn.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list collections: %w", err)
+	}
+
+	result := make([]string, 0, len(names))
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, "system.") {
+			result = append(result, name)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
 // newDatabase creates a new database.
 func newDatabase(conn *mongo.Database, opts ...DatabaseOption) *database {
 	d := &database{
@@ -109,3 +364,10 @@ func CleanUpAfterScenario(collections ...string) DatabaseOption {
 		d.cleanUps = append(d.cleanUps, collections...)
 	})
 }
+
+// CompareDocumentsInAnyOrder compares the expected and actual documents in the collection irrespective of their order.
+func CompareDocumentsInAnyOrder() DatabaseOption {
+	return databaseOptionFunc(func(d *database) {
+		d.anyOrder = true
+	})
+}
@@ -0,0 +1,132 @@
+package mongosteps
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestDatabase_StoreAll(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		reader          func() docsReader
+		allowEmpty      bool
+		expectedBatches []int
+		expectedError   string
+	}{
+		{
+			scenario: "no documents",
+			reader: func() docsReader {
+				return &docsSliceReader{}
+			},
+			expectedError: `could not insert documents into collection "customer": must provide at least one element in input slice`,
+		},
+		{
+			scenario: "no documents allowed",
+			reader: func() docsReader {
+				return &docsSliceReader{}
+			},
+			allowEmpty:      true,
+			expectedBatches: []int{},
+		},
+		{
+			scenario: "read error",
+			reader: func() docsReader {
+				return newNDJSONReader(strings.NewReader("{\"name\":\"John Doe\"}\nmalformed"), nil)
+			},
+			expectedError: `could not read documents for collection "customer": line 2: error unmarshaling extjson: invalid JSON input`,
+		},
+		{
+			scenario: "one batch",
+			reader: func() docsReader {
+				return &docsSliceReader{docs: newDocs(3)}
+			},
+			expectedBatches: []int{3},
+		},
+		{
+			scenario: "many batches",
+			reader: func() docsReader {
+				return &docsSliceReader{docs: newDocs(2*storeBatchSize + 1)}
+			},
+			expectedBatches: []int{storeBatchSize, storeBatchSize, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			for range tc.expectedBatches {
+				t.AddMockResponses(mtest.CreateSuccessResponse())
+			}
+
+			err := newDatabase(t.DB).storeAll(context.Background(), "customer", tc.reader(), tc.allowEmpty)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			events := t.GetAllStartedEvents()
+			actualBatches := make([]int, len(events))
+
+			for i, e := range events {
+				docs, err := e.Command.Lookup("documents").Array().Values()
+				assert.NoError(t, err)
+
+				actualBatches[i] = len(docs)
+			}
+
+			assert.Equal(t, tc.expectedBatches, actualBatches)
+		})
+	}
+}
+
+func newDocs(n int) []bsoncore.Document {
+	docs := make([]bsoncore.Document, n)
+
+	for i := range docs {
+		docs[i] = bsoncore.NewDocumentBuilder().AppendInt32("n", int32(i)).Build()
+	}
+
+	return docs
+}
+
+func TestDatabase_CleanUp(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("registered collections", func(t *mtest.T) {
+		t.Parallel()
+
+		t.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		d := newDatabase(t.DB, CleanUpAfterScenario("customer", "order"))
+
+		err := d.cleanUp(context.Background(), "order", "invoice")
+		assert.NoError(t, err)
+
+		events := t.GetAllStartedEvents()
+		actual := make([]string, len(events))
+
+		for i, e := range events {
+			actual[i] = e.Command.Lookup("delete").StringValue()
+		}
+
+		assert.Equal(t, []string{"customer", "order", "invoice"}, actual)
+	})
+}
@@ -0,0 +1,324 @@
+package mongosteps
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// DiffFormat is the format of the report of the documents that are not equal.
+type DiffFormat int
+
+const (
+	// DiffFormatJSON reports the JSON diff of all the documents.
+	DiffFormatJSON DiffFormat = iota
+	// DiffFormatReport reports the missing, the unexpected and the mismatched documents, with the paths of the fields
+	// that differ.
+	DiffFormatReport
+	// DiffFormatText reports a unified diff of the documents in indented JSON.
+	DiffFormatText
+)
+
+var htmlUnescaper = strings.NewReplacer(`\u003c`, "<", `\u003e`, ">", `\u0026`, "&")
+
+// documentPair is the index of an expected document and the index of its actual document, -1 when there is none.
+type documentPair struct {
+	expected int
+	actual   int
+}
+
+// reportDiff checks whether the expected and actual documents are equal, in the same order, and reports the
+// differences per document. The documents are paired by their _id when all the expected documents have one, by their
+// positions otherwise.
+func reportDiff(expected, actual []bsoncore.Document) error {
+	pairs, byID := pairDocumentsForReport(expected, actual)
+
+	label := func(docs []bsoncore.Document, i int) string {
+		if byID {
+			if id, err := docs[i].LookupErr("_id"); err == nil {
+				return "document _id " + valueText(id)
+			}
+		}
+
+		return "document #" + strconv.Itoa(i+1)
+	}
+
+	var report strings.Builder
+
+	missing, unexpected, mismatched := 0, 0, 0
+
+	for _, p := range pairs {
+		switch {
+		case p.actual < 0:
+			missing++
+
+			_, _ = fmt.Fprintf(&report, "\nmissing %s:\n%s\n", label(expected, p.expected), prettyDocument(expected[p.expected]))
+
+		case p.expected < 0:
+			unexpected++
+
+			_, _ = fmt.Fprintf(&report, "\nunexpected %s:\n%s\n", label(actual, p.actual), prettyDocument(actual[p.actual]))
+
+		default:
+			diffs := diffDocument("", expected[p.expected], actual[p.actual])
+			if len(diffs) == 0 {
+				continue
+			}
+
+			mismatched++
+
+			_, _ = fmt.Fprintf(&report, "\n%s:\n", label(expected, p.expected))
+
+			for _, d := range diffs {
+				_, _ = fmt.Fprintf(&report, "  %s\n", d)
+			}
+		}
+	}
+
+	if missing+unexpected+mismatched > 0 {
+		return fmt.Errorf("%d missing, %d unexpected and %d mismatched document(s)\n%s", missing, unexpected, mismatched, report.String()) // nolint: goerr113
+	}
+
+	for i, p := range pairs {
+		if p.actual != i {
+			return fmt.Errorf("the documents are not in the expected order, expected _id(s) %s, got %s", documentIDs(expected), documentIDs(actual)) // nolint: goerr113
+		}
+	}
+
+	return nil
+}
+
+// pairDocumentsForReport pairs the expected and actual documents by their _id when all the expected documents have
+// one, or by their positions. The unexpected documents are paired last.
+func pairDocumentsForReport(expected, actual []bsoncore.Document) ([]documentPair, bool) {
+	ids := make([]bsoncore.Value, len(expected))
+
+	for i, doc := range expected {
+		id, err := doc.LookupErr("_id")
+		if err != nil || isIgnoreDiff(id) {
+			return pairDocumentsByPosition(expected, actual), false
+		}
+
+		if _, _, ok := parseMatcher(id); ok {
+			return pairDocumentsByPosition(expected, actual), false
+		}
+
+		ids[i] = id
+	}
+
+	pairs := make([]documentPair, 0, len(expected)+len(actual))
+	paired := make([]bool, len(actual))
+
+	for i, id := range ids {
+		p := documentPair{expected: i, actual: -1}
+
+		for j, doc := range actual {
+			if v, err := doc.LookupErr("_id"); err == nil && !paired[j] && v.Equal(id) {
+				p.actual, paired[j] = j, true
+
+				break
+			}
+		}
+
+		pairs = append(pairs, p)
+	}
+
+	for j, ok := range paired {
+		if !ok {
+			pairs = append(pairs, documentPair{expected: -1, actual: j})
+		}
+	}
+
+	return pairs, true
+}
+
+func pairDocumentsByPosition(expected, actual []bsoncore.Document) []documentPair {
+	n := len(expected)
+	if len(actual) > n {
+		n = len(actual)
+	}
+
+	pairs := make([]documentPair, n)
+
+	for i := range pairs {
+		pairs[i] = documentPair{expected: i, actual: i}
+
+		if i >= len(expected) {
+			pairs[i].expected = -1
+		}
+
+		if i >= len(actual) {
+			pairs[i].actual = -1
+		}
+	}
+
+	return pairs
+}
+
+func documentIDs(docs []bsoncore.Document) string {
+	ids := make([]string, len(docs))
+
+	for i, doc := range docs {
+		ids[i] = valueText(doc.Lookup("_id"))
+	}
+
+	return "[" + strings.Join(ids, ", ") + "]"
+}
+
+// diffDocument returns the differences between the expected and the actual document, one per field path, like
+// `address.city: expected "City 1", got "City 9"`.
+func diffDocument(prefix string, expected, actual bsoncore.Document) []string {
+	var diffs []string
+
+	expectedElems, err := expected.Elements()
+	if err != nil {
+		return []string{fmt.Sprintf("%s: invalid expected document: %s", prefix, err)}
+	}
+
+	actualElems, err := actual.Elements()
+	if err != nil {
+		return []string{fmt.Sprintf("%s: invalid actual document: %s", prefix, err)}
+	}
+
+	for _, e := range expectedElems {
+		path := fieldPath(prefix, e.Key())
+
+		a, err := actual.LookupErr(e.Key())
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("%s: expected %s, got nothing", path, valueText(e.Value())))
+
+			continue
+		}
+
+		diffs = append(diffs, diffValue(path, e.Value(), a)...)
+	}
+
+	for _, a := range actualElems {
+		if _, err := expected.LookupErr(a.Key()); err != nil {
+			diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", fieldPath(prefix, a.Key()), valueText(a.Value())))
+		}
+	}
+
+	return diffs
+}
+
+func diffValue(path string, expected, actual bsoncore.Value) []string {
+	if isIgnoreDiff(expected) {
+		return nil
+	}
+
+	if name, arg, ok := parseMatcher(expected); ok {
+		if matchMatcher(name, arg, actual) {
+			return nil
+		}
+
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, valueText(expected), valueText(actual))}
+	}
+
+	switch {
+	case expected.Type == bsontype.EmbeddedDocument && actual.Type == bsontype.EmbeddedDocument:
+		return diffDocument(path, expected.Document(), actual.Document())
+
+	case expected.Type == bsontype.Array && actual.Type == bsontype.Array:
+		return diffArray(path, expected.Array(), actual.Array())
+
+	case !expected.Equal(actual):
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, valueText(expected), valueText(actual))}
+	}
+
+	return nil
+}
+
+func diffArray(path string, expected, actual bsoncore.Array) []string {
+	expectedValues, err := expected.Values()
+	if err != nil {
+		return []string{fmt.Sprintf("%s: invalid expected array: %s", path, err)}
+	}
+
+	actualValues, err := actual.Values()
+	if err != nil {
+		return []string{fmt.Sprintf("%s: invalid actual array: %s", path, err)}
+	}
+
+	var diffs []string
+
+	for i := 0; i < len(expectedValues) && i < len(actualValues); i++ {
+		diffs = append(diffs, diffValue(fieldPath(path, strconv.Itoa(i)), expectedValues[i], actualValues[i])...)
+	}
+
+	if len(expectedValues) != len(actualValues) {
+		diffs = append(diffs, fmt.Sprintf("%s: expected %d element(s), got %d", path, len(expectedValues), len(actualValues)))
+	}
+
+	return diffs
+}
+
+// valueText returns the value in canonical ExtJSON, without escaping the < and > of the matchers.
+func valueText(v bsoncore.Value) string {
+	return htmlUnescaper.Replace(v.String())
+}
+
+func fieldPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+// textDiff checks whether the expected and actual documents are equal, in the same order, and reports a unified diff
+// of the documents in indented JSON. The values of the actual documents that are ignored or that satisfy a matcher are
+// shown as in the expected documents.
+func textDiff(expected, actual []bsoncore.Document) error {
+	expectedDocs := make([]bson.D, len(expected))
+	actualDocs := make([]bson.D, len(actual))
+
+	for i, raw := range expected {
+		if err := bson.Unmarshal(raw, &expectedDocs[i]); err != nil {
+			return fmt.Errorf("could not read expected document #%d: %w", i+1, err)
+		}
+	}
+
+	for i, raw := range actual {
+		if err := bson.Unmarshal(raw, &actualDocs[i]); err != nil {
+			return fmt.Errorf("could not read actual document #%d: %w", i+1, err)
+		}
+
+		if i < len(expectedDocs) {
+			actualDocs[i] = keepIgnoredDiffs(expectedDocs[i], actualDocs[i])
+		}
+	}
+
+	expectedText, err := encodeExpectedDocs("", expectedDocs)
+	if err != nil {
+		return fmt.Errorf("failed to convert expected documents to JSON: %w", err)
+	}
+
+	actualText, err := encodeExpectedDocs("", actualDocs)
+	if err != nil {
+		return fmt.Errorf("failed to convert actual documents to JSON: %w", err)
+	}
+
+	if bytes.Equal(expectedText, actualText) {
+		return nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.TrimSuffix(string(expectedText), "\n")),
+		B:        difflib.SplitLines(strings.TrimSuffix(string(actualText), "\n")),
+		FromFile: "expected",
+		ToFile:   "actual",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to diff documents: %w", err)
+	}
+
+	return fmt.Errorf("not equal:\n%s", diff) // nolint: goerr113
+}
@@ -0,0 +1,136 @@
+package mongosteps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportDiff(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		expected      string
+		actual        string
+		expectedError string
+	}{
+		{
+			scenario: "equal",
+			expected: `[{"_id": 1, "name": "John"}, {"_id": 2, "name": "Jane"}]`,
+			actual:   `[{"_id": 1, "name": "John"}, {"_id": 2, "name": "Jane"}]`,
+		},
+		{
+			scenario: "ignore diff and matchers",
+			expected: `[{"_id": "<ignore-diff>", "name": "<regex:^J>", "tags": ["<len:3>"]}]`,
+			actual:   `[{"_id": {"$oid": "6250053966df8910f804c3a7"}, "name": "John", "tags": ["vip"]}]`,
+		},
+		{
+			scenario: "paired by id",
+			expected: `[
+				{"_id": 1, "name": "John", "address": {"city": "City 1"}, "tags": ["a", "b"], "total": "<gt:10>"},
+				{"_id": 2, "name": "Jane"},
+				{"_id": 3, "name": "Jim"}
+			]`,
+			actual: `[
+				{"_id": 1, "name": "John", "address": {"city": "City 9"}, "tags": ["a"], "total": 5, "age": 30},
+				{"_id": 3, "name": "Jim"},
+				{"_id": 4, "name": "Joe"}
+			]`,
+			expectedError: `1 missing, 1 unexpected and 1 mismatched document(s)
+
+document _id {"$numberInt":"1"}:
+  address.city: expected "City 1", got "City 9"
+  tags: expected 2 element(s), got 1
+  total: expected "<gt:10>", got {"$numberInt":"5"}
+  age: unexpected {"$numberInt":"30"}
+
+missing document _id {"$numberInt":"2"}:
+{
+    "_id": {
+        "$numberInt": "2"
+    },
+    "name": "Jane"
+}
+
+unexpected document _id {"$numberInt":"4"}:
+{
+    "_id": {
+        "$numberInt": "4"
+    },
+    "name": "Joe"
+}
+`,
+		},
+		{
+			scenario: "paired by position",
+			expected: `[{"_id": "<ignore-diff>", "name": "John", "tags": ["a", "b"]}, {"name": "Jane"}]`,
+			actual:   `[{"_id": 1, "tags": ["a", "c"]}]`,
+			expectedError: `1 missing, 0 unexpected and 1 mismatched document(s)
+
+document #1:
+  name: expected "John", got nothing
+  tags.1: expected "b", got "c"
+
+missing document #2:
+{
+    "name": "Jane"
+}
+`,
+		},
+		{
+			scenario:      "different order",
+			expected:      `[{"_id": 2, "name": "Jane"}, {"_id": 3, "name": "Jim"}]`,
+			actual:        `[{"_id": 3, "name": "Jim"}, {"_id": 2, "name": "Jane"}]`,
+			expectedError: `the documents are not in the expected order, expected _id(s) [{"$numberInt":"2"}, {"$numberInt":"3"}], got [{"$numberInt":"3"}, {"$numberInt":"2"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			err := reportDiff(mustParseDocs([]byte(tc.expected)), mustParseDocs([]byte(tc.actual)))
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestTextDiff(t *testing.T) {
+	t.Parallel()
+
+	err := textDiff(
+		mustParseDocs([]byte(`[{"_id": "<ignore-diff>", "name": "<regex:^J>", "address": {"city": "City 1"}}]`)),
+		mustParseDocs([]byte(`[{"_id": 1, "name": "John", "address": {"city": "City 1"}}]`)),
+	)
+	assert.NoError(t, err)
+
+	err = textDiff(
+		mustParseDocs([]byte(`[{"_id": "<ignore-diff>", "name": "John", "total": "<gt:10>"}]`)),
+		mustParseDocs([]byte(`[{"_id": 1, "name": "Jane", "total": 5}]`)),
+	)
+
+	assert.EqualError(t, err, `not equal:
+--- expected
++++ actual
+@@ -1,7 +1,9 @@
+ [
+     {
+         "_id": "<ignore-diff>",
+-        "name": "John",
+-        "total": "<gt:10>"
++        "name": "Jane",
++        "total": {
++            "$numberInt": "5"
++        }
+     }
+ ]
+`)
+}
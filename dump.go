@@ -0,0 +1,345 @@
+package mongosteps
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+const (
+	// archiveMagicNumber is at the beginning of a mongodump archive.
+	archiveMagicNumber uint32 = 0x8199e26d
+
+	// archiveTerminator is the size of a BSON document that ends a block of a mongodump archive.
+	archiveTerminator int32 = -1
+
+	minBSONSize = 5
+	maxBSONSize = 16*1024*1024 + 16*1024
+)
+
+// errArchiveTerminator is returned when a terminator is read instead of a document.
+var errArchiveTerminator = errors.New("unexpected archive terminator")
+
+// dumpMetadata is the metadata of a collection in a mongodump, as in the metadata.json file.
+type dumpMetadata struct {
+	Options bson.D   `bson:"options"`
+	Indexes []bson.D `bson:"indexes"`
+}
+
+// archiveCollection is the metadata of a collection in the prelude of a mongodump archive.
+type archiveCollection struct {
+	Database   string `bson:"db"`
+	Collection string `bson:"collection"`
+	Metadata   string `bson:"metadata"`
+}
+
+// archiveNamespace is the header of a block of documents in a mongodump archive.
+type archiveNamespace struct {
+	Database   string `bson:"db"`
+	Collection string `bson:"collection"`
+	EOF        bool   `bson:"EOF"`
+}
+
+// bsonReader reads the concatenated BSON documents of a mongodump .bson file.
+type bsonReader struct {
+	r      io.Reader
+	closer io.Closer
+}
+
+func (r *bsonReader) Next() (bsoncore.Document, error) {
+	doc, err := readBSON(r.r)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		return nil, errArchiveTerminator
+	}
+
+	return doc, nil
+}
+
+func (r *bsonReader) Close() error {
+	return r.closer.Close()
+}
+
+// openDumpFile opens a mongodump file, which is decompressed when it is gzipped.
+func openDumpFile(filePath string) (*bufio.Reader, io.Closer, error) {
+	f, err := os.Open(path.Clean(filePath))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := bufio.NewReader(f)
+
+	if magic, err := r.Peek(2); err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return r, f, nil
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		_ = f.Close() // nolint: errcheck
+
+		return nil, nil, fmt.Errorf("could not decompress %q: %w", filePath, err)
+	}
+
+	return bufio.NewReader(gz), f, nil
+}
+
+// readDumpMetadata reads the metadata.json file that is next to a mongodump .bson file, it returns nil if there is no
+// metadata.
+func readDumpMetadata(filePath string) (*dumpMetadata, error) {
+	base := strings.TrimSuffix(strings.TrimSuffix(filePath, ".gz"), ".bson")
+
+	for _, metadataPath := range []string{base + ".metadata.json", base + ".metadata.json.gz"} {
+		r, closer, err := openDumpFile(metadataPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(r)
+		_ = closer.Close() // nolint: errcheck
+
+		if err != nil {
+			return nil, err
+		}
+
+		return parseDumpMetadata(data)
+	}
+
+	return nil, nil // nolint: nilnil
+}
+
+func parseDumpMetadata(data []byte) (*dumpMetadata, error) {
+	var m dumpMetadata
+
+	if err := bson.UnmarshalExtJSON(data, false, &m); err != nil {
+		return nil, fmt.Errorf("could not parse dump metadata: %w", err)
+	}
+
+	return &m, nil
+}
+
+// readBSON reads a BSON document that is prefixed by its size, it returns a nil document for an archive terminator.
+func readBSON(r io.Reader) (bsoncore.Document, error) {
+	var size [4]byte
+
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+
+	n := int32(binary.LittleEndian.Uint32(size[:]))
+
+	if n == archiveTerminator {
+		return nil, nil
+	}
+
+	if n < minBSONSize || n > maxBSONSize {
+		return nil, fmt.Errorf("invalid document size %d", n) // nolint: goerr113
+	}
+
+	doc := make(bsoncore.Document, n)
+	copy(doc, size[:])
+
+	if _, err := io.ReadFull(r, doc[4:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// restoreMetadata creates the collection with the options of the dump, unless it already exists, and its indexes.
+func (d *database) restoreMetadata(ctx context.Context, collection string, m *dumpMetadata) error {
+	if len(m.Options) > 0 {
+		exists, err := d.hasCollection(ctx, collection)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			if err := d.createCollectionWithCommandOptions(ctx, collection, m.Options); err != nil {
+				return err
+			}
+		}
+	}
+
+	indexes := make([]bson.D, 0, len(m.Indexes))
+
+	for _, index := range m.Indexes {
+		name, key := "", bson.D(nil)
+		cleaned := make(bson.D, 0, len(index))
+
+		for _, e := range index {
+			switch e.Key {
+			case "v", "ns":
+				continue
+			case "name":
+				name, _ = e.Value.(string) // nolint: errcheck
+			case "key":
+				key, _ = e.Value.(bson.D) // nolint: errcheck
+			}
+
+			cleaned = append(cleaned, e)
+		}
+
+		if name == "_id_" || len(key) == 0 {
+			continue
+		}
+
+		indexes = append(indexes, cleaned)
+	}
+
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	return d.createIndexes(ctx, collection, indexes)
+}
+
+// restoreArchive restores all the collections of a mongodump archive, whatever their database in the archive. It returns
+// the collections that are restored, even partially when there is an error.
+func (d *database) restoreArchive(ctx context.Context, r io.Reader) ([]string, error) {
+	var magic [4]byte
+
+	if _, err := io.ReadFull(r, magic[:]); err != nil || binary.LittleEndian.Uint32(magic[:]) != archiveMagicNumber {
+		return nil, errors.New("not a mongodump archive") // nolint: goerr113
+	}
+
+	// The header of the archive has only the versions of the server and the tools.
+	if _, err := readBSON(r); err != nil {
+		return nil, fmt.Errorf("could not read archive header: %w", err)
+	}
+
+	collections, err := d.restoreArchivePrelude(ctx, r)
+	if err != nil {
+		return collections, err
+	}
+
+	restored := func(collection string) {
+		if !containsString(collections, collection) {
+			collections = append(collections, collection)
+		}
+	}
+
+	batches := make(map[string][]bsoncore.Document)
+
+	for {
+		header, err := readBSON(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return collections, fmt.Errorf("could not read archive: %w", err)
+		}
+
+		if header == nil {
+			continue
+		}
+
+		var ns archiveNamespace
+
+		if err := bson.Unmarshal(header, &ns); err != nil {
+			return collections, fmt.Errorf("could not read archive namespace: %w", err)
+		}
+
+		for {
+			doc, err := readBSON(r)
+			if err != nil {
+				return collections, fmt.Errorf("could not read documents of collection %q from archive: %w", ns.Collection, err)
+			}
+
+			if doc == nil {
+				break
+			}
+
+			if ns.EOF || strings.HasPrefix(ns.Collection, "system.") {
+				continue
+			}
+
+			restored(ns.Collection)
+
+			if batches[ns.Collection] = append(batches[ns.Collection], doc); len(batches[ns.Collection]) < storeBatchSize {
+				continue
+			}
+
+			if err := d.store(ctx, ns.Collection, batches[ns.Collection]); err != nil {
+				return collections, err
+			}
+
+			batches[ns.Collection] = nil
+		}
+	}
+
+	for collection, batch := range batches {
+		if len(batch) == 0 {
+			continue
+		}
+
+		if err := d.store(ctx, collection, batch); err != nil {
+			return collections, err
+		}
+	}
+
+	return collections, nil
+}
+
+// restoreArchivePrelude restores the metadata of the collections in the prelude of a mongodump archive, and returns
+// these collections.
+func (d *database) restoreArchivePrelude(ctx context.Context, r io.Reader) ([]string, error) {
+	var collections []string
+
+	for {
+		doc, err := readBSON(r)
+		if err != nil {
+			return collections, fmt.Errorf("could not read archive prelude: %w", err)
+		}
+
+		if doc == nil {
+			return collections, nil
+		}
+
+		var c archiveCollection
+
+		if err := bson.Unmarshal(doc, &c); err != nil {
+			return collections, fmt.Errorf("could not read archive prelude: %w", err)
+		}
+
+		if c.Collection == "" || c.Metadata == "" || strings.HasPrefix(c.Collection, "system.") {
+			continue
+		}
+
+		m, err := parseDumpMetadata([]byte(c.Metadata))
+		if err != nil {
+			return collections, err
+		}
+
+		collections = append(collections, c.Collection)
+
+		if err := d.restoreMetadata(ctx, c.Collection, m); err != nil {
+			return collections, err
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package mongosteps
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestReadBSON(t *testing.T) {
+	t.Parallel()
+
+	doc := bsoncore.NewDocumentBuilder().AppendString("name", "John Doe").Build()
+
+	testCases := []struct {
+		scenario      string
+		data          []byte
+		expected      bsoncore.Document
+		expectedError string
+	}{
+		{
+			scenario:      "end of file",
+			expectedError: `EOF`,
+		},
+		{
+			scenario: "document",
+			data:     doc,
+			expected: doc,
+		},
+		{
+			scenario: "terminator",
+			data:     []byte{0xFF, 0xFF, 0xFF, 0xFF},
+		},
+		{
+			scenario:      "invalid size",
+			data:          []byte{0x02, 0x00, 0x00, 0x00},
+			expectedError: `invalid document size 2`,
+		},
+		{
+			scenario:      "truncated document",
+			data:          doc[:len(doc)-2],
+			expectedError: `unexpected EOF`,
+		},
+		{
+			scenario:      "invalid document",
+			data:          []byte{0x05, 0x00, 0x00, 0x00, 0x01},
+			expectedError: `invalid document: document or array end is missing null byte`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := readBSON(bytes.NewReader(tc.data))
+
+			assert.Equal(t, tc.expected, actual)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestBSONReader(t *testing.T) {
+	t.Parallel()
+
+	r, closer, err := openDumpFile("resources/fixtures/dump/customer.bson")
+	assert.NoError(t, err)
+
+	docs, err := readAllDocs(&bsonReader{r: r, closer: closer})
+	assert.NoError(t, err)
+
+	assert.Equal(t, mustParseDocs(readFixtures("resources/fixtures/customers.json")), docs)
+
+	_, err = (&bsonReader{r: bytes.NewReader([]byte{0xFF, 0xFF, 0xFF, 0xFF})}).Next()
+	assert.True(t, errors.Is(err, errArchiveTerminator))
+
+	_, err = (&bsonReader{r: bytes.NewReader(nil)}).Next()
+	assert.True(t, errors.Is(err, io.EOF))
+
+	assert.NoError(t, closer.Close())
+}
+
+func TestReadDumpMetadata(t *testing.T) {
+	t.Parallel()
+
+	m, err := readDumpMetadata("resources/fixtures/dump/customer.bson")
+	assert.NoError(t, err)
+
+	raw, err := bson.Marshal(m)
+	assert.NoError(t, err)
+
+	actual, err := docsToExtJSON([]bsoncore.Document{raw})
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `[{
+		"options": {},
+		"indexes": [
+			{"v": {"$numberInt": "2"}, "key": {"_id": {"$numberInt": "1"}}, "name": "_id_"},
+			{"v": {"$numberInt": "2"}, "key": {"name": {"$numberInt": "1"}}, "name": "name_1"}
+		]
+	}]`, string(actual))
+
+	m, err = readDumpMetadata("resources/fixtures/customers.json")
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+}
@@ -0,0 +1,88 @@
+package mongosteps
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	writeErrorDuplicateKey = "duplicate key"
+	writeErrorValidation   = "validation"
+	writeErrorOther        = "other"
+
+	// codeDocumentValidationFailure is the server error code of a document that fails the collection validator.
+	codeDocumentValidationFailure = 121
+)
+
+// writeError is a failed write classified by its server error code.
+type writeError struct {
+	Kind    string
+	Code    int
+	Message string
+	Details bson.Raw
+}
+
+// isServerWriteError checks whether the error is a write rejected by the server, not a client, network or context
+// error.
+func isServerWriteError(err error) bool {
+	var (
+		bwe mongo.BulkWriteException
+		we  mongo.WriteException
+		ce  mongo.CommandError
+	)
+
+	switch {
+	case errors.As(err, &bwe):
+		return len(bwe.WriteErrors) > 0 || bwe.WriteConcernError != nil
+
+	case errors.As(err, &we):
+		return len(we.WriteErrors) > 0 || we.WriteConcernError != nil
+
+	case errors.As(err, &ce):
+		return ce.Code != 0
+	}
+
+	return false
+}
+
+// classifyWriteError classifies the first write error of a failed write.
+func classifyWriteError(err error) writeError {
+	result := writeError{Kind: writeErrorOther, Message: err.Error()}
+
+	var (
+		bwe mongo.BulkWriteException
+		we  mongo.WriteException
+	)
+
+	switch {
+	case errors.As(err, &bwe) && len(bwe.WriteErrors) > 0:
+		result.Code = bwe.WriteErrors[0].Code
+		result.Message = bwe.WriteErrors[0].Message
+		result.Details = bwe.WriteErrors[0].Details
+
+	case errors.As(err, &we) && len(we.WriteErrors) > 0:
+		result.Code = we.WriteErrors[0].Code
+		result.Message = we.WriteErrors[0].Message
+		result.Details = we.WriteErrors[0].Details
+
+	default:
+		var ce mongo.CommandError
+
+		if errors.As(err, &ce) {
+			result.Code = int(ce.Code)
+			result.Message = ce.Message
+		}
+	}
+
+	switch {
+	case mongo.IsDuplicateKeyError(err):
+		result.Kind = writeErrorDuplicateKey
+
+	case result.Code == codeDocumentValidationFailure:
+		result.Kind = writeErrorValidation
+	}
+
+	return result
+}
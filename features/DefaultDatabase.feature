@@ -215,3 +215,622 @@ Feature: Success cases with all the steps of the default database.
             }
         ]
         """
+
+    Scenario: Update documents matching a query
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        When documents matching query in collection "customer" are updated with:
+        """
+        {
+            "filter": {"name": "John Doe"},
+            "update": {
+                "$set": {"address.city": "City 3"},
+                "$inc": {"age": 1}
+            }
+        }
+        """
+
+        Then 1 document was matched
+        And 1 document was modified
+        And collection "customer" should have only these documents:
+        """
+        [
+            {
+                "_id": "<ignored-diff>",
+                "name": "John Doe",
+                "age": 31,
+                "address": {
+                    "street": "Street 1",
+                    "city": "City 3",
+                    "country": "Country 1"
+                }
+            },
+            {
+                "_id": "<ignored-diff>",
+                "name": "Jane Doe",
+                "age": 20,
+                "address": {
+                    "street": "Street 2",
+                    "city": "City 2",
+                    "country": "Country 2"
+                }
+            }
+        ]
+        """
+
+    Scenario: Upsert documents matching a query
+        Given no documents in collection "customer"
+
+        When documents matching query in collection "customer" are upserted with:
+        """
+        {
+            "filter": {"_id": {"$oid": "6250053966df8910f804c3a7"}},
+            "update": {"$set": {"name": "John Doe"}}
+        }
+        """
+
+        Then 0 documents were matched
+        And 1 document was upserted
+        And there are only these documents in collection "customer":
+        """
+        [
+            {
+                "_id": {"$oid": "6250053966df8910f804c3a7"},
+                "name": "John Doe"
+            }
+        ]
+        """
+
+        When documents matching query in collection "customer" are upserted with:
+        """
+        {
+            "filter": {"_id": {"$oid": "6250053966df8910f804c3a7"}},
+            "update": {"$unset": {"name": ""}}
+        }
+        """
+
+        Then 1 document was matched
+        And 1 document was modified
+        And 0 documents were upserted
+
+    Scenario: Delete documents matching a query
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        When documents matching query are deleted from collection "customer":
+        """
+        {
+            "age": {
+                "$gt": 25
+            }
+        }
+        """
+
+        Then 1 document was deleted
+        And there are only these documents in collection "customer":
+        """
+        [
+            {
+                "_id": "<ignored-diff>",
+                "name": "Jane Doe",
+                "age": 20,
+                "address": {
+                    "street": "Street 2",
+                    "city": "City 2",
+                    "country": "Country 2"
+                }
+            }
+        ]
+        """
+
+    Scenario: Collection should contain the documents
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        Then collection "customer" should contain these documents:
+        """
+        [
+            {
+                "name": "Jane Doe",
+                "address": {
+                    "city": "City 2"
+                }
+            },
+            {
+                "name": "John Doe"
+            }
+        ]
+        """
+
+    Scenario: Search result should contain the documents
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        When I search in collection "customer"
+
+        Then the result should contain this document:
+        """
+        [
+            {
+                "name": "Jane Doe",
+                "age": 20
+            }
+        ]
+        """
+
+    Scenario: Collection should have only the documents in any order
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        Then collection "customer" should have only these documents in any order:
+        """
+        [
+            {
+                "_id": "<ignored-diff>",
+                "name": "Jane Doe",
+                "age": 20,
+                "address": "<ignored-diff>"
+            },
+            {
+                "_id": "<ignored-diff>",
+                "name": "John Doe",
+                "age": 30,
+                "address": "<ignored-diff>"
+            }
+        ]
+        """
+
+        When I search in collection "customer"
+
+        Then I found these documents in the result in any order:
+        """
+        [
+            {
+                "_id": "<ignored-diff>",
+                "name": "Jane Doe",
+                "age": 20,
+                "address": "<ignored-diff>"
+            },
+            {
+                "_id": "<ignored-diff>",
+                "name": "John Doe",
+                "age": 30,
+                "address": "<ignored-diff>"
+            }
+        ]
+        """
+
+    Scenario: Count documents matching a query
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        Then there is 1 document in collection "customer" matching:
+        """
+        {"age": {"$gt": 25}}
+        """
+        And there are at least 2 documents in collection "customer" matching:
+        """
+        {"address.country": {"$exists": true}}
+        """
+        And there are at most 1 documents in collection "customer" matching:
+        """
+        {"name": "Jane Doe"}
+        """
+        And collection "customer" should have more than 0 documents matching:
+        """
+        {"name": "John Doe"}
+        """
+        And collection "customer" should have fewer than 1 document matching:
+        """
+        {"name": "Jack Doe"}
+        """
+
+    Scenario: Search in collection with find options
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        When I search in collection "customer" with query:
+        """
+        {
+            "filter": {"age": {"$gt": 10}},
+            "sort": {"age": 1},
+            "projection": {"_id": 0, "name": 1},
+            "limit": 1,
+            "skip": 1
+        }
+        """
+
+        Then I found these documents in the result:
+        """
+        [
+            {
+                "name": "John Doe"
+            }
+        ]
+        """
+
+    Scenario: Run aggregation on collection
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        When I run aggregation on collection "customer":
+        """
+        [
+            {"$match": {"age": {"$gte": 18}}},
+            {"$group": {"_id": "$address.country", "total": {"$sum": "$age"}}},
+            {"$sort": {"_id": 1}}
+        ]
+        """
+
+        Then I found 2 documents in the result
+        And I found these documents in the result:
+        """
+        [
+            {"_id": "Country 1", "total": 30},
+            {"_id": "Country 2", "total": 20}
+        ]
+        """
+
+    Scenario: Eventually the documents are available
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        Then there are 2 documents in collection "customer" eventually
+        And collection "customer" should have 2 documents within 1s
+        And collection "customer" should have only these documents within 500ms:
+        """
+        [
+            {
+                "_id": "<ignored-diff>",
+                "name": "John Doe",
+                "age": 30,
+                "address": "<ignored-diff>"
+            },
+            {
+                "_id": "<ignored-diff>",
+                "name": "Jane Doe",
+                "age": 20,
+                "address": "<ignored-diff>"
+            }
+        ]
+        """
+
+        When I search in collection "customer" with query:
+        """
+        {"age": {"$gt": 25}}
+        """
+
+        Then I found 1 document in the result eventually
+        And this document is in the result within 1s:
+        """
+        [
+            {
+                "_id": "<ignored-diff>",
+                "name": "John Doe",
+                "age": 30,
+                "address": "<ignored-diff>"
+            }
+        ]
+        """
+
+    Scenario: Manage indexes of collection
+        Given all indexes are dropped from collection "customer"
+        And these indexes are created in collection "customer":
+        """
+        [
+            {"key": {"name": 1}, "unique": true},
+            {"key": {"address.city": 1, "age": -1}, "name": "city_age"}
+        ]
+        """
+
+        Then collection "customer" should have these indexes:
+        """
+        [
+            {"key": {"_id": 1}, "name": "_id_"},
+            {"key": {"name": 1}, "name": "name_1", "unique": true},
+            {"key": {"address.city": 1, "age": -1}, "name": "city_age"}
+        ]
+        """
+
+        When index "city_age" is dropped from collection "customer"
+
+        Then collection "customer" should have these indexes:
+        """
+        [
+            {"key": {"_id": 1}, "name": "_id_"},
+            {"key": {"name": 1}, "name": "name_1", "unique": "<ignore-diff>"}
+        ]
+        """
+
+        When all indexes are dropped from collection "customer"
+
+        Then collection "customer" should have this index:
+        """
+        [
+            {"key": {"_id": 1}, "name": "_id_"}
+        ]
+        """
+
+    Scenario: Storing duplicate documents should fail
+        Given all indexes are dropped from collection "customer"
+        And this index is created in collection "customer":
+        """
+        [
+            {"key": {"name": 1}, "unique": true}
+        ]
+        """
+        And these documents are stored in collection "customer":
+        """
+        [
+            {"name": "John Doe"}
+        ]
+        """
+
+        Then storing this document in collection "customer" should fail with duplicate key error:
+        """
+        [
+            {"name": "John Doe"}
+        ]
+        """
+        And the write error code should be 11000
+        And the write error message should contain "duplicate key"
+        And all indexes are dropped from collection "customer"
+
+    Scenario: Manage collection lifecycle
+        Given collection "archive" is dropped
+        And collection "archived_customer" is dropped
+        And collection "archive" is created with options:
+        """
+        {
+            "capped": true,
+            "size": 4096
+        }
+        """
+
+        Then collection "archive" should exist
+
+        When collection "archive" is renamed to "archived_customer"
+
+        Then collection "archive" should not exist
+        And collection "archived_customer" should exist
+
+        When collection "archived_customer" is dropped
+
+        Then collection "archived_customer" should not exist
+
+    Scenario: Documents are rejected by the collection validator
+        Given collection "validated_customer" is dropped
+        And collection "validated_customer" is created
+        And the validator is applied to collection "validated_customer":
+        """
+        {
+            "validator": {"$jsonSchema": {"required": ["name"]}},
+            "validationLevel": "strict",
+            "validationAction": "error"
+        }
+        """
+
+        Then this document should be rejected by collection "validated_customer":
+        """
+        [
+            {"age": 30}
+        ]
+        """
+        And the write error code should be 121
+        And collection "validated_customer" should have 0 documents
+        And collection "validated_customer" is dropped
+
+    Scenario: Documents as tables
+        Given no documents in collection "customer"
+        And these rows are stored in collection "customer":
+            | _id                          | name     | age | address.city | active |
+            | oid:6250053966df8910f804c3a7 | John Doe | 30  | City 1       | true   |
+            | oid:6250053966df8910f804c3a8 | Jane Doe | 25  |              | false  |
+
+        Then there are only these rows in collection "customer":
+            | _id                          | name     | age | address.city | active |
+            | oid:6250053966df8910f804c3a7 | John Doe | 30  | City 1       | true   |
+            | oid:6250053966df8910f804c3a8 | Jane Doe | 25  |              | false  |
+
+        When I search in collection "customer" with query:
+        """
+        {"active": true}
+        """
+
+        Then these rows are in the result:
+            | _id           | name     | age | address.city | active |
+            | <ignore-diff> | John Doe | 30  | City 1       | true   |
+
+    Scenario: Documents in YAML
+        Given no documents in collection "customer"
+        And documents from file "../../resources/fixtures/customers.yaml" are stored in collection "customer"
+
+        Then there are only these documents from file "../../resources/fixtures/customers.json" in collection "customer"
+
+        Given no documents in collection "customer"
+        And these documents are stored in collection "customer":
+        """yaml
+        - _id:
+            $oid: 6250053966df8910f804c3a9
+          name: Jack Doe
+          registered:
+            $date: 2022-04-08T10:00:00Z
+        """
+
+        Then there are only these documents in collection "customer":
+        """yaml
+        - _id:
+            $oid: 6250053966df8910f804c3a9
+          name: Jack Doe
+          registered: 2022-04-08T10:00:00Z
+        """
+
+    Scenario: Documents in NDJSON
+        Given no documents in collection "customer"
+        And documents from file "../../resources/fixtures/customers.ndjson" are stored in collection "customer"
+
+        Then there are only these documents from file "../../resources/fixtures/customers.json" in collection "customer"
+
+        Given no documents in collection "customer"
+        And these documents are stored in collection "customer":
+        """ndjson
+        {"_id": {"$oid": "6250053966df8910f804c3a9"}, "name": "Jack Doe", "registered": {"$date": "2022-04-08T10:00:00Z"}}
+        """
+
+        Then there are only these documents in collection "customer":
+        """
+        [
+            {
+                "_id": {"$oid": "6250053966df8910f804c3a9"},
+                "name": "Jack Doe",
+                "registered": {"$date": {"$numberLong": "1649412000000"}}
+            }
+        ]
+        """
+
+    Scenario: Restore documents from mongodump
+        Given no documents in collection "customer"
+        And all indexes are dropped from collection "customer"
+        And documents from dump "../../resources/fixtures/dump/customer.bson" are restored into collection "customer"
+
+        Then there are only these documents from file "../../resources/fixtures/customers.json" in collection "customer"
+        And collection "customer" should have these indexes:
+        """
+        [
+            {"key": {"_id": 1}, "name": "_id_"},
+            {"key": {"name": 1}, "name": "name_1"}
+        ]
+        """
+
+        Given no documents in collection "customer"
+        And all indexes are dropped from collection "customer"
+        And dump archive "../../resources/fixtures/dump/test.archive.gz" is restored
+
+        Then there are only these documents from file "../../resources/fixtures/customers.json" in collection "customer"
+        And all indexes are dropped from collection "customer"
+
+    Scenario: Seed database from directory
+        Given database is seeded from directory "../../resources/fixtures/seed/basic"
+
+        Then there are only these documents from file "../../resources/fixtures/customers.json" in collection "customer"
+        And there are 2 documents in collection "order"
+        And there are 2 documents in collection "invoice"
+
+    Scenario: Database matches directory
+        Given database is seeded from directory "../../resources/fixtures/seed/basic"
+
+        Then database should match directory "../../resources/fixtures/seed/basic"
+
+    Scenario: Use variables in documents
+        Given no documents in collection "order"
+        And documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        When I search in collection "customer" with query:
+        """
+        {"filter": {"name": "Jane Doe"}}
+        """
+        And I remember field "_id" of document 1 in the result as "$customerID"
+        And I remember field "address.city" of document 1 in the result as "$city"
+
+        And these documents are stored in collection "order":
+        """
+        [{"customer_id": "$customerID", "city": "$city", "ref": "ORD-{{ .customerID }}"}]
+        """
+
+        Then collection "order" should have only these documents:
+        """
+        [
+            {
+                "_id": "<ignore-diff>",
+                "customer_id": {"$oid": "6250053966df8910f804c3a8"},
+                "city": "City 2",
+                "ref": "ORD-6250053966df8910f804c3a8"
+            }
+        ]
+        """
+
+    Scenario: Generate values in documents
+        Given no documents in collection "order"
+
+        When these documents are stored in collection "order":
+        """
+        [
+            {"_id": {"$newOid": "first"}, "ref": {"$uuid": "ref"}, "created_at": {"$now": "-24h"}},
+            {"_id": {"$newOid": "second"}, "ref": {"$uuid": "ref"}, "created_at": {"$now": {}}}
+        ]
+        """
+
+        Then collection "order" should have only these documents:
+        """
+        [
+            {"_id": "$first", "ref": {"$uuid": "ref"}, "created_at": "<ignore-diff>"},
+            {"_id": {"$newOid": "second"}, "ref": "$ref", "created_at": "<ignore-diff>"}
+        ]
+        """
+
+    Scenario: Match documents with typed matchers
+        Given no documents in collection "order"
+
+        When these documents are stored in collection "order":
+        """
+        [
+            {
+                "_id": {"$oid": "6250053966df8910f804c3b1"},
+                "ref": "ORD-42",
+                "total": {"$numberLong": "120"},
+                "status": "paid",
+                "items": ["book", "pen", "bag"],
+                "created_at": {"$date": "2022-04-08T10:00:00.5Z"}
+            }
+        ]
+        """
+
+        Then collection "order" should have only these documents:
+        """
+        [
+            {
+                "_id": "<is-oid>",
+                "ref": "<regex:^ORD-\\d+$>",
+                "total": "<gt:100>",
+                "status": "<any-of:new,paid>",
+                "items": "<len:3>",
+                "created_at": "<date-within:1s,2022-04-08T10:00:00Z>"
+            }
+        ]
+        """
+
+    Scenario: Assert fields of the documents in the search result
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+
+        When I search in collection "customer" with query:
+        """
+        {"sort": {"_id": 1}}
+        """
+
+        Then field "address.city" of document 2 in the result should be "City 2"
+        And field "age" of the first result should be 30
+        And field "_id" of the first result should be "<is-oid>"
+        And field "name" of the first result should contain "Doe"
+        And all documents in the result should have field "address.street" equal to "<regex:^Street \\d$>"
+
+    Scenario: Compare named search results
+        Given documents from file "../../resources/fixtures/customers.json" are stored in collection "customer"
+        And I search in collection "customer" with query as "before":
+        """
+        {"sort": {"_id": 1}}
+        """
+
+        When documents matching query in collection "customer" are updated with:
+        """
+        {
+            "filter": {"name": "John Doe"},
+            "update": {"$inc": {"age": 1}}
+        }
+        """
+        And I search in collection "customer" with query as "after":
+        """
+        {"sort": {"_id": 1}}
+        """
+
+        Then the result "before" should have 2 documents
+        And the result "after" should equal result "before" except field "age"
+        And field "age" of the first result should be 31
+        And the result "before" should have these documents:
+        """
+        [
+            {"_id": "<is-oid>", "name": "John Doe", "age": 30, "address": "<len:3>"},
+            {"_id": "<is-oid>", "name": "Jane Doe", "age": 20, "address": "<len:3>"}
+        ]
+        """
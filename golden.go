@@ -0,0 +1,267 @@
+package mongosteps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"gopkg.in/yaml.v3"
+)
+
+// updateExpectedFileEnv is the environment variable that enables the update of the expected files.
+const updateExpectedFileEnv = "MONGOSTEPS_UPDATE_EXPECTED_FILES"
+
+// updateExpectedFile rewrites a file of expected documents with the actual documents in canonical ExtJSON, in the
+// format of the file. The <ignore-diff> placeholders and the satisfied matchers of the expected documents are kept at
+// the same paths of their actual documents, which are paired by _id when all the expected documents have one, by
+// position otherwise. The raw documents are the expected documents before the resolution of their variables and
+// generated values, which are kept where the actual values are still the resolved ones.
+func updateExpectedFile(filePath, mediaType string, rawDocs, expectedDocs, actualDocs []bsoncore.Document) error {
+	docs := make([]bson.D, len(actualDocs))
+
+	for i, raw := range actualDocs {
+		if err := bson.Unmarshal(raw, &docs[i]); err != nil {
+			return fmt.Errorf("could not read actual document #%d: %w", i+1, err)
+		}
+	}
+
+	pairs, _ := pairDocumentsForReport(expectedDocs, actualDocs)
+
+	for _, p := range pairs {
+		if p.expected < 0 || p.actual < 0 {
+			continue
+		}
+
+		var expected bson.D
+
+		if err := bson.Unmarshal(expectedDocs[p.expected], &expected); err != nil {
+			return fmt.Errorf("could not read expected document #%d: %w", p.expected+1, err)
+		}
+
+		docs[p.actual] = keepIgnoredDiffs(expected, docs[p.actual])
+
+		if p.expected >= len(rawDocs) {
+			continue
+		}
+
+		var raw bson.D
+
+		if err := bson.Unmarshal(rawDocs[p.expected], &raw); err != nil {
+			return fmt.Errorf("could not read expected document #%d: %w", p.expected+1, err)
+		}
+
+		docs[p.actual] = keepRawValues(raw, expected, docs[p.actual])
+	}
+
+	data, err := encodeExpectedDocs(mediaType, docs)
+	if err != nil {
+		return fmt.Errorf("could not update %q: %w", filePath, err)
+	}
+
+	if err := os.WriteFile(path.Clean(filePath), data, 0o644); err != nil { // nolint: gosec
+		return fmt.Errorf("could not update %q: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// keepIgnoredDiffs puts the <ignore-diff> placeholders of the expected document in the actual document, at the same
+// paths.
+func keepIgnoredDiffs(expected, actual bson.D) bson.D {
+	for _, e := range expected {
+		for i, a := range actual {
+			if a.Key == e.Key {
+				actual[i].Value = keepIgnoredDiffValue(e.Value, a.Value)
+			}
+		}
+	}
+
+	return actual
+}
+
+func keepIgnoredDiffValue(expected, actual interface{}) interface{} {
+	switch e := expected.(type) {
+	case string:
+		if e == ignoreDiff || e == ignoredDiff || matchesActualValue(e, actual) {
+			return e
+		}
+
+	case bson.D:
+		if a, ok := actual.(bson.D); ok {
+			return keepIgnoredDiffs(e, a)
+		}
+
+	case bson.A:
+		if a, ok := actual.(bson.A); ok {
+			for i := 0; i < len(e) && i < len(a); i++ {
+				a[i] = keepIgnoredDiffValue(e[i], a[i])
+			}
+
+			return a
+		}
+	}
+
+	return actual
+}
+
+// keepRawValues puts the raw values of the expected document in the actual document, at the same paths, where they
+// differ from their resolved values and the actual values are the resolved ones.
+func keepRawValues(raw, resolved, actual bson.D) bson.D {
+	for _, r := range raw {
+		for _, e := range resolved {
+			if e.Key != r.Key {
+				continue
+			}
+
+			for i, a := range actual {
+				if a.Key == r.Key {
+					actual[i].Value = keepRawValue(r.Value, e.Value, a.Value)
+				}
+			}
+		}
+	}
+
+	return actual
+}
+
+func keepRawValue(raw, resolved, actual interface{}) interface{} {
+	switch r := raw.(type) {
+	case bson.D:
+		e, eok := resolved.(bson.D)
+		a, aok := actual.(bson.D)
+
+		if eok && aok {
+			return keepRawValues(r, e, a)
+		}
+
+	case bson.A:
+		e, eok := resolved.(bson.A)
+		a, aok := actual.(bson.A)
+
+		if eok && aok && len(r) == len(e) {
+			for i := 0; i < len(r) && i < len(a); i++ {
+				a[i] = keepRawValue(r[i], e[i], a[i])
+			}
+
+			return a
+		}
+	}
+
+	if !equalValues(raw, resolved) && equalValues(resolved, actual) {
+		return raw
+	}
+
+	return actual
+}
+
+// equalValues checks whether the values have the same BSON type and data.
+func equalValues(v1, v2 interface{}) bool {
+	t1, data1, err := bson.MarshalValue(v1)
+	if err != nil {
+		return false
+	}
+
+	t2, data2, err := bson.MarshalValue(v2)
+	if err != nil {
+		return false
+	}
+
+	return t1 == t2 && bytes.Equal(data1, data2)
+}
+
+// matchesActualValue checks whether the expected value is a matcher that is satisfied by the actual value.
+func matchesActualValue(expected string, actual interface{}) bool {
+	match := matcherPattern.FindStringSubmatch(expected)
+	if match == nil {
+		return false
+	}
+
+	t, data, err := bson.MarshalValue(actual)
+	if err != nil {
+		return false
+	}
+
+	return matchMatcher(match[1], match[2], bsoncore.Value{Type: t, Data: data})
+}
+
+// encodeExpectedDocs encodes the documents in canonical ExtJSON, as a JSON array, one document per line for NDJSON, or
+// as YAML.
+func encodeExpectedDocs(mediaType string, docs []bson.D) ([]byte, error) {
+	values := make([]json.RawMessage, len(docs))
+
+	for i, doc := range docs {
+		data, err := bson.MarshalExtJSON(doc, true, false)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling document #%d: %w", i+1, err)
+		}
+
+		values[i] = data
+	}
+
+	var buf bytes.Buffer
+
+	if isNDJSONMediaType(mediaType) {
+		for _, v := range values {
+			buf.Write(v)
+			buf.WriteByte('\n')
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if !isYAMLMediaType(mediaType) {
+		enc.SetIndent("", "    ")
+	}
+
+	if err := enc.Encode(values); err != nil {
+		return nil, fmt.Errorf("error marshaling documents: %w", err)
+	}
+
+	if !isYAMLMediaType(mediaType) {
+		return buf.Bytes(), nil
+	}
+
+	return extJSONToYAML(buf.Bytes())
+}
+
+// extJSONToYAML converts ExtJSON to YAML in block style, keeping the order of the fields.
+func extJSONToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("error converting to yaml: %w", err)
+	}
+
+	resetYAMLStyle(&node)
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&node); err != nil {
+		return nil, fmt.Errorf("error marshaling yaml: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("error marshaling yaml: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// resetYAMLStyle clears the flow and quoted styles of the JSON syntax, the encoder quotes the strings that need it.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
+}
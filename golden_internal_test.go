@@ -0,0 +1,189 @@
+package mongosteps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestKeepIgnoredDiffs(t *testing.T) {
+	t.Parallel()
+
+	expected := mustParseBSOND([]byte(`{
+		"_id": "<ignore-diff>",
+		"name": "John Doe",
+		"address": {"city": "<ignored-diff>"},
+		"tags": ["vip", "<ignore-diff>"],
+		"total": "<gt:10>",
+		"status": "<any-of:new,paid>",
+		"missing": "<ignore-diff>"
+	}`))
+
+	actual := mustParseBSOND([]byte(`{
+		"_id": {"$oid": "6250053966df8910f804c3a7"},
+		"name": "Jane Doe",
+		"address": {"street": "Street 2", "city": "City 2"},
+		"tags": ["new", "premium", "gold"],
+		"total": 12,
+		"status": "cancelled"
+	}`))
+
+	result, err := bson.MarshalExtJSON(keepIgnoredDiffs(expected, actual), true, false)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"_id": "<ignore-diff>",
+		"name": "Jane Doe",
+		"address": {"street": "Street 2", "city": "<ignored-diff>"},
+		"tags": ["new", "<ignore-diff>", "gold"],
+		"total": "<gt:10>",
+		"status": "cancelled"
+	}`, string(result))
+}
+
+func TestEncodeExpectedDocs(t *testing.T) {
+	t.Parallel()
+
+	docs := []bson.D{
+		mustParseBSOND([]byte(`{"_id": "<ignore-diff>", "name": "John Doe", "age": 30, "tags": ["vip"]}`)),
+		mustParseBSOND([]byte(`{"_id": {"$oid": "6250053966df8910f804c3a8"}, "name": "123"}`)),
+	}
+
+	testCases := []struct {
+		scenario  string
+		mediaType string
+		docs      []bson.D
+		expected  string
+	}{
+		{
+			scenario: "json",
+			docs:     docs,
+			expected: `[
+    {
+        "_id": "<ignore-diff>",
+        "name": "John Doe",
+        "age": {
+            "$numberInt": "30"
+        },
+        "tags": [
+            "vip"
+        ]
+    },
+    {
+        "_id": {
+            "$oid": "6250053966df8910f804c3a8"
+        },
+        "name": "123"
+    }
+]
+`,
+		},
+		{
+			scenario: "json without documents",
+			expected: "[]\n",
+		},
+		{
+			scenario:  "ndjson",
+			mediaType: mediaTypeNDJSON,
+			docs:      docs,
+			expected: `{"_id":"<ignore-diff>","name":"John Doe","age":{"$numberInt":"30"},"tags":["vip"]}
+{"_id":{"$oid":"6250053966df8910f804c3a8"},"name":"123"}
+`,
+		},
+		{
+			scenario:  "yaml",
+			mediaType: mediaTypeYAML,
+			docs:      docs,
+			expected: `- _id: <ignore-diff>
+  name: John Doe
+  age:
+    $numberInt: "30"
+  tags:
+    - vip
+- _id:
+    $oid: 6250053966df8910f804c3a8
+  name: "123"
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := encodeExpectedDocs(tc.mediaType, tc.docs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestUpdateExpectedFile(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "customer.yaml")
+
+	expected := mustParseDocs([]byte(`[{"_id": "<ignore-diff>", "name": "John Doe"}]`))
+	actual := mustParseDocs([]byte(`[
+		{"_id": {"$oid": "6250053966df8910f804c3a7"}, "name": "Jane Doe"},
+		{"_id": {"$oid": "6250053966df8910f804c3a8"}, "name": "John Doe"}
+	]`))
+
+	err := updateExpectedFile(filePath, mediaTypeYAML, expected, expected, actual)
+	assert.NoError(t, err)
+
+	data, err := fileToDocString(filePath)
+	assert.NoError(t, err)
+
+	docs, err := stringToDocs(data)
+	assert.NoError(t, err)
+
+	result, err := docsToExtJSON(docs)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `[
+		{"_id": "<ignore-diff>", "name": "Jane Doe"},
+		{"_id": {"$oid": "6250053966df8910f804c3a8"}, "name": "John Doe"}
+	]`, string(result))
+
+	// The documents are paired by _id, the placeholders follow their documents.
+	expected = mustParseDocs([]byte(`[
+		{"_id": 1, "name": "John Doe", "createdAt": "<ignore-diff>"},
+		{"_id": 2, "name": "Jane Doe", "tags": "<len:1>"}
+	]`))
+	actual = mustParseDocs([]byte(`[
+		{"_id": 3, "name": "Jim Doe", "createdAt": "2020-01-01"},
+		{"_id": 2, "name": "Jane Doe", "tags": ["vip"]},
+		{"_id": 1, "name": "John Doe", "createdAt": "2020-01-02"}
+	]`))
+
+	err = updateExpectedFile(filePath, "", expected, expected, actual)
+	assert.NoError(t, err)
+
+	data, err = fileToDocString(filePath)
+	assert.NoError(t, err)
+
+	docs, err = stringToDocs(data)
+	assert.NoError(t, err)
+
+	result, err = docsToExtJSON(docs)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `[
+		{"_id": {"$numberInt": "3"}, "name": "Jim Doe", "createdAt": "2020-01-01"},
+		{"_id": {"$numberInt": "2"}, "name": "Jane Doe", "tags": "<len:1>"},
+		{"_id": {"$numberInt": "1"}, "name": "John Doe", "createdAt": "<ignore-diff>"}
+	]`, string(result))
+
+	err = updateExpectedFile(filepath.Join(t.TempDir(), "unknown", "customer.json"), "", nil, nil, actual)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	err = updateExpectedFile(filePath, "", nil, []bsoncore.Document{{0x01}}, actual)
+	assert.EqualError(t, err, "could not read expected document #1: EOF")
+}
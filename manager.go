@@ -2,18 +2,32 @@ package mongosteps
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/swaggest/assertjson"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 )
 
-const defaultDatabase = "default"
+const (
+	defaultDatabase = "default"
+
+	defaultPollInterval = 100 * time.Millisecond
+	defaultPollTimeout  = 5 * time.Second
+
+	// eventuallyPattern matches "eventually" for the default poll timeout or "within <duration>" for a specific one.
+	eventuallyPattern = `(?:eventually|within ((?:[0-9]+(?:\.[0-9]+)?(?:ns|us|ms|s|m|h))+))`
+)
 
 // ManagerOption sets an option on the Manager.
 type ManagerOption interface {
@@ -29,13 +43,21 @@ func (f managerOptionFunc) applyManagerOption(m *Manager) {
 // Manager manages all databases for running cucumber steps.
 type Manager struct {
 	databases map[string]*database
+
+	pollInterval time.Duration
+	pollTimeout  time.Duration
+
+	updateExpectedFiles bool
+	diffFormat          DiffFormat
+
+	variables VariableStore
 }
 
 // RegisterContext registers the manager to godog scenarios.
 func (m *Manager) RegisterContext(sc *godog.ScenarioContext) {
 	sc.After(func(ctx context.Context, _ *godog.Scenario, _ error) (context.Context, error) {
-		for _, db := range m.databases {
-			if err := db.cleanUp(ctx); err != nil {
+		for name, db := range m.databases {
+			if err := db.cleanUp(ctx, cleanUpsFromContext(ctx, name)...); err != nil {
 				return ctx, err
 			}
 		}
@@ -61,12 +83,54 @@ func (m *Manager) registerSteps(sc *godog.ScenarioContext) {
 		},
 	)
 
+	sc.Step(`(?:this row is|these rows are)(?: stored)? in collection "([^"]*)"[:]?$`,
+		func(ctx context.Context, collectionName string, table *godog.Table) (context.Context, error) {
+			return m.theseRowsAreStoredInCollectionOfDatabase(ctx, collectionName, defaultDatabase, table)
+		},
+	)
+
 	sc.Step(`(?:docs|documents) from(?: file)? "([^"]*)" are(?: stored)? in collection "([^"]*)"$`,
 		func(ctx context.Context, filePath, collectionName string) (context.Context, error) {
 			return m.theseDocumentsFromFileAreStoredInCollectionOfDatabase(ctx, filePath, collectionName, defaultDatabase)
 		},
 	)
 
+	sc.Step(`(?:docs|documents) from dump "([^"]*)" are restored (?:in|into) collection "([^"]*)"$`,
+		func(ctx context.Context, filePath, collectionName string) (context.Context, error) {
+			return m.restoreDumpToCollectionOfDatabase(ctx, filePath, collectionName, defaultDatabase)
+		},
+	)
+
+	sc.Step(`dump archive "([^"]*)" is restored$`,
+		func(ctx context.Context, filePath string) (context.Context, error) {
+			return m.restoreDumpArchiveToDatabase(ctx, filePath, defaultDatabase)
+		},
+	)
+
+	sc.Step(`(?:the )?database is seeded from directory "([^"]*)"$`,
+		func(ctx context.Context, dirPath string) (context.Context, error) {
+			return m.seedDatabaseFromDirectory(ctx, defaultDatabase, dirPath)
+		},
+	)
+
+	sc.Step(`(?:docs|documents) matching query in collection "([^"]*)" (?:is|are) updated with[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.updateDocumentsInCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`(?:docs|documents) matching query in collection "([^"]*)" (?:is|are) upserted with[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.upsertDocumentsInCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`(?:docs|documents) matching query (?:is|are) deleted from collection "([^"]*)"[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.deleteDocumentsFromCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
 	sc.Step(`(?:search|find) in collection "([^"]*)"$`,
 		func(ctx context.Context, collectionName string) (context.Context, error) {
 			return m.searchInCollectionOfDatabase(ctx, collectionName, defaultDatabase, nil)
@@ -79,10 +143,119 @@ func (m *Manager) registerSteps(sc *godog.ScenarioContext) {
 		},
 	)
 
+	sc.Step(`run aggregation on collection "([^"]*)"[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.runAggregationOnCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`(?:search|find) in collection "([^"]*)" as "([^"]*)"$`,
+		func(ctx context.Context, collectionName, name string) (context.Context, error) {
+			return m.searchInCollectionOfDatabaseAs(ctx, collectionName, defaultDatabase, name, nil)
+		},
+	)
+
+	sc.Step(`(?:search|find) in collection "([^"]*)" with query as "([^"]*)"[:]?$`,
+		func(ctx context.Context, collectionName, name string, data *godog.DocString) (context.Context, error) {
+			return m.searchInCollectionOfDatabaseAs(ctx, collectionName, defaultDatabase, name, data)
+		},
+	)
+
+	sc.Step(`run aggregation on collection "([^"]*)" as "([^"]*)"[:]?$`,
+		func(ctx context.Context, collectionName, name string, data *godog.DocString) (context.Context, error) {
+			return m.runAggregationOnCollectionOfDatabaseAs(ctx, collectionName, defaultDatabase, name, data)
+		},
+	)
+
+	sc.Step(`storing (?:this|these) (?:doc|docs|document|documents) in collection "([^"]*)" should fail(?: with (duplicate key|validation) error)?[:]?$`,
+		func(ctx context.Context, collectionName, kind string, data *godog.DocString) (context.Context, error) {
+			return m.storingDocumentsInCollectionOfDatabaseShouldFail(ctx, collectionName, defaultDatabase, kind, data)
+		},
+	)
+
+	sc.Step(`(?:this|the) validator is applied to collection "([^"]*)"[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.applyValidatorToCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`(?:this index is|these indexes are) created in collection "([^"]*)"[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.createIndexesInCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`index "([^"]*)" is dropped from collection "([^"]*)"$`,
+		func(ctx context.Context, indexName, collectionName string) (context.Context, error) {
+			return m.dropIndexFromCollectionOfDatabase(ctx, indexName, collectionName, defaultDatabase)
+		},
+	)
+
+	sc.Step(`all indexes are dropped from collection "([^"]*)"$`,
+		func(ctx context.Context, collectionName string) (context.Context, error) {
+			return m.dropAllIndexesFromCollectionOfDatabase(ctx, collectionName, defaultDatabase)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" is created$`,
+		func(ctx context.Context, collectionName string) (context.Context, error) {
+			return m.createCollectionInDatabase(ctx, collectionName, defaultDatabase, nil)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" is created with options[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.createCollectionInDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" is dropped$`,
+		func(ctx context.Context, collectionName string) (context.Context, error) {
+			return m.dropCollectionFromDatabase(ctx, collectionName, defaultDatabase)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" is renamed to "([^"]*)"$`,
+		func(ctx context.Context, collectionName, newName string) (context.Context, error) {
+			return m.renameCollectionInDatabase(ctx, collectionName, defaultDatabase, newName)
+		},
+	)
+
+	sc.Step(`remember field "([^"]*)" of document ([0-9]+) in the result as "([^"]*)"$`, m.rememberFieldOfDocumentInSearchResult)
+
 	sc.Step(`no (?:docs|documents) in collection "([^"]*)" of database "([^"]*)"$`, m.noDocumentsInCollectionOfDatabase)
 	sc.Step(`these (?:docs|documents) are(?: stored)? in collection "([^"]*)" of database "([^"]*)"[:]?$`, m.theseDocumentsAreStoredInCollectionOfDatabase)
+	sc.Step(`(?:this row is|these rows are)(?: stored)? in collection "([^"]*)" of database "([^"]*)"[:]?$`, m.theseRowsAreStoredInCollectionOfDatabase)
 	sc.Step(`(?:docs|documents) from(?: file)? "([^"]*)" are(?: stored)? in collection "([^"]*)" of database "([^"]*)"[:]?$`, m.theseDocumentsFromFileAreStoredInCollectionOfDatabase)
+	sc.Step(`(?:docs|documents) from dump "([^"]*)" are restored (?:in|into) collection "([^"]*)" of database "([^"]*)"$`, m.restoreDumpToCollectionOfDatabase)
+	sc.Step(`dump archive "([^"]*)" is restored (?:in|into) database "([^"]*)"$`, m.restoreDumpArchiveToDatabase)
+	sc.Step(`database "([^"]*)" is seeded from directory "([^"]*)"$`, m.seedDatabaseFromDirectory)
+	sc.Step(`(?:docs|documents) matching query in collection "([^"]*)" of database "([^"]*)" (?:is|are) updated with[:]?$`, m.updateDocumentsInCollectionOfDatabase)
+	sc.Step(`(?:docs|documents) matching query in collection "([^"]*)" of database "([^"]*)" (?:is|are) upserted with[:]?$`, m.upsertDocumentsInCollectionOfDatabase)
+	sc.Step(`(?:docs|documents) matching query (?:is|are) deleted from collection "([^"]*)" of database "([^"]*)"[:]?$`, m.deleteDocumentsFromCollectionOfDatabase)
 	sc.Step(`(?:search|find) in collection "([^"]*)" of database "([^"]*)" with query[:]?$`, m.searchInCollectionOfDatabase)
+	sc.Step(`run aggregation on collection "([^"]*)" of database "([^"]*)"[:]?$`, m.runAggregationOnCollectionOfDatabase)
+	sc.Step(`(?:search|find) in collection "([^"]*)" of database "([^"]*)" as "([^"]*)"$`,
+		func(ctx context.Context, collectionName, dbName, name string) (context.Context, error) {
+			return m.searchInCollectionOfDatabaseAs(ctx, collectionName, dbName, name, nil)
+		},
+	)
+	sc.Step(`(?:search|find) in collection "([^"]*)" of database "([^"]*)" with query as "([^"]*)"[:]?$`, m.searchInCollectionOfDatabaseAs)
+	sc.Step(`run aggregation on collection "([^"]*)" of database "([^"]*)" as "([^"]*)"[:]?$`, m.runAggregationOnCollectionOfDatabaseAs)
+	sc.Step(`storing (?:this|these) (?:doc|docs|document|documents) in collection "([^"]*)" of database "([^"]*)" should fail(?: with (duplicate key|validation) error)?[:]?$`, m.storingDocumentsInCollectionOfDatabaseShouldFail)
+	sc.Step(`(?:this|the) validator is applied to collection "([^"]*)" of database "([^"]*)"[:]?$`, m.applyValidatorToCollectionOfDatabase)
+	sc.Step(`(?:this index is|these indexes are) created in collection "([^"]*)" of database "([^"]*)"[:]?$`, m.createIndexesInCollectionOfDatabase)
+	sc.Step(`index "([^"]*)" is dropped from collection "([^"]*)" of database "([^"]*)"$`, m.dropIndexFromCollectionOfDatabase)
+	sc.Step(`all indexes are dropped from collection "([^"]*)" of database "([^"]*)"$`, m.dropAllIndexesFromCollectionOfDatabase)
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" is created with options[:]?$`, m.createCollectionInDatabase)
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" is dropped$`, m.dropCollectionFromDatabase)
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" is renamed to "([^"]*)"$`, m.renameCollectionInDatabase)
+
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" is created$`,
+		func(ctx context.Context, collectionName, databaseName string) (context.Context, error) {
+			return m.createCollectionInDatabase(ctx, collectionName, databaseName, nil)
+		},
+	)
 
 	sc.Step(`(?:search|find) in collection "([^"]*)" of database "([^"]*)"$`,
 		func(ctx context.Context, collectionName, databaseName string) (context.Context, error) {
@@ -123,17 +296,81 @@ func (m *Manager) registerAssertions(sc *godog.ScenarioContext) {
 		},
 	)
 
+	sc.Step(`there (?:is|are) only (?:this|these) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" in any order[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.haveOnlyTheseDocumentsInAnyOrderAvailableInCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" should have only (?:this|these) (?:doc|docs|document|documents)(?: available)? in any order[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.haveOnlyTheseDocumentsInAnyOrderAvailableInCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
 	sc.Step(`there (?:is|are) only (?:this|these) (?:doc|docs|document|documents) from(?: file)? "([^"]*)"(?: available)? in collection "([^"]*)"[:]?$`,
 		func(ctx context.Context, filePath, collectionName string) (context.Context, error) {
 			return m.haveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(ctx, filePath, collectionName, defaultDatabase)
 		},
 	)
 
+	sc.Step(`(?:the )?database should match directory "([^"]*)"( and have no other collections)?$`,
+		func(ctx context.Context, dirPath, noOtherCollections string) (context.Context, error) {
+			return m.haveDatabaseMatchingDirectory(ctx, defaultDatabase, dirPath, noOtherCollections)
+		},
+	)
+
 	sc.Step(`no (?:docs|documents) are(?: available)? in collection "([^"]*)" of database "([^"]*)"$`, m.noDocumentsAreAvailableInCollectionOfDatabase)
+	sc.Step(`database "([^"]*)" should match directory "([^"]*)"( and have no other collections)?$`, m.haveDatabaseMatchingDirectory)
 	sc.Step(`there (?:is|are) ([0-9]+) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" of database "([^"]*)"$`, m.haveNumberOfDocumentsAvailableInCollectionOfDatabase)
 	sc.Step(`there (?:is|are) only (?:this|these) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" of database "([^"]*)"[:]?$`, m.haveOnlyTheseDocumentsAvailableInCollectionOfDatabase)
 	sc.Step(`collection "([^"]*)" of database "([^"]*)" should have only (?:this|these) (?:doc|docs|document|documents)(?: available)?[:]?$`, m.haveOnlyTheseDocumentsAvailableInCollectionOfDatabase)
 	sc.Step(`there (?:is|are) only (?:this|these) (?:doc|docs|document|documents) from(?: file)? "([^"]*)"(?: available)? in collection "([^"]*)" of database "([^"]*)"[:]?$`, m.haveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase)
+	sc.Step(`there (?:is|are) only (?:this|these) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" of database "([^"]*)" in any order[:]?$`, m.haveOnlyTheseDocumentsInAnyOrderAvailableInCollectionOfDatabase)
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should have only (?:this|these) (?:doc|docs|document|documents)(?: available)? in any order[:]?$`, m.haveOnlyTheseDocumentsInAnyOrderAvailableInCollectionOfDatabase)
+
+	sc.Step(`there (?:is|are) only (?:this row|these rows)(?: available)? in collection "([^"]*)"( in any order)?[:]?$`,
+		func(ctx context.Context, collectionName, order string, table *godog.Table) (context.Context, error) {
+			return m.haveOnlyTheseRowsInCollectionOfDatabase(ctx, collectionName, defaultDatabase, order, table)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" should have only (?:this row|these rows)(?: available)?( in any order)?[:]?$`,
+		func(ctx context.Context, collectionName, order string, table *godog.Table) (context.Context, error) {
+			return m.haveOnlyTheseRowsInCollectionOfDatabase(ctx, collectionName, defaultDatabase, order, table)
+		},
+	)
+
+	sc.Step(`there (?:is|are) only (?:this row|these rows)(?: available)? in collection "([^"]*)" of database "([^"]*)"( in any order)?[:]?$`, m.haveOnlyTheseRowsInCollectionOfDatabase)
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should have only (?:this row|these rows)(?: available)?( in any order)?[:]?$`, m.haveOnlyTheseRowsInCollectionOfDatabase)
+
+	sc.Step(`there (?:is|are) (?:(at least|at most|more than|less than|fewer than) )?([0-9]+) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" matching[:]?$`,
+		func(ctx context.Context, comparison string, count int64, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.haveNumberOfDocumentsMatchingInCollectionOfDatabase(ctx, comparison, count, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" should have (?:(at least|at most|more than|less than|fewer than) )?([0-9]+) (?:doc|docs|document|documents)(?: available)? matching[:]?$`,
+		func(ctx context.Context, collectionName, comparison string, count int64, data *godog.DocString) (context.Context, error) {
+			return m.haveNumberOfDocumentsMatchingInCollectionOfDatabase(ctx, comparison, count, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`there (?:is|are) (?:(at least|at most|more than|less than|fewer than) )?([0-9]+) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" of database "([^"]*)" matching[:]?$`, m.haveNumberOfDocumentsMatchingInCollectionOfDatabase)
+
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should have (?:(at least|at most|more than|less than|fewer than) )?([0-9]+) (?:doc|docs|document|documents)(?: available)? matching[:]?$`,
+		func(ctx context.Context, collectionName, databaseName, comparison string, count int64, data *godog.DocString) (context.Context, error) {
+			return m.haveNumberOfDocumentsMatchingInCollectionOfDatabase(ctx, comparison, count, collectionName, databaseName, data)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" should contain (?:this|these) (?:doc|docs|document|documents)[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.containTheseDocumentsInCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should contain (?:this|these) (?:doc|docs|document|documents)[:]?$`, m.containTheseDocumentsInCollectionOfDatabase)
 
 	sc.Step(`collection "([^"]*)" of database "([^"]*)" should have ([0-9]+) (?:doc|docs|document|documents)(?: available)?$`,
 		func(ctx context.Context, collectionName, databaseName string, count int64) (context.Context, error) {
@@ -141,10 +378,106 @@ func (m *Manager) registerAssertions(sc *godog.ScenarioContext) {
 		},
 	)
 
+	sc.Step(`there (?:is|are) ([0-9]+) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" `+eventuallyPattern+`$`,
+		func(ctx context.Context, count int64, collectionName, timeout string) (context.Context, error) {
+			return m.eventuallyHaveNumberOfDocumentsAvailableInCollectionOfDatabase(ctx, count, collectionName, defaultDatabase, timeout)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" should have ([0-9]+) (?:doc|docs|document|documents)(?: available)? `+eventuallyPattern+`$`,
+		func(ctx context.Context, collectionName string, count int64, timeout string) (context.Context, error) {
+			return m.eventuallyHaveNumberOfDocumentsAvailableInCollectionOfDatabase(ctx, count, collectionName, defaultDatabase, timeout)
+		},
+	)
+
+	sc.Step(`there (?:is|are) only (?:this|these) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" `+eventuallyPattern+`[:]?$`,
+		func(ctx context.Context, collectionName, timeout string, data *godog.DocString) (context.Context, error) {
+			return m.eventuallyHaveOnlyTheseDocumentsAvailableInCollectionOfDatabase(ctx, collectionName, defaultDatabase, timeout, data)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" should have only (?:this|these) (?:doc|docs|document|documents)(?: available)? `+eventuallyPattern+`[:]?$`,
+		func(ctx context.Context, collectionName, timeout string, data *godog.DocString) (context.Context, error) {
+			return m.eventuallyHaveOnlyTheseDocumentsAvailableInCollectionOfDatabase(ctx, collectionName, defaultDatabase, timeout, data)
+		},
+	)
+
+	sc.Step(`there (?:is|are) ([0-9]+) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" of database "([^"]*)" `+eventuallyPattern+`$`, m.eventuallyHaveNumberOfDocumentsAvailableInCollectionOfDatabase)
+	sc.Step(`there (?:is|are) only (?:this|these) (?:doc|docs|document|documents)(?: available)? in collection "([^"]*)" of database "([^"]*)" `+eventuallyPattern+`[:]?$`, m.eventuallyHaveOnlyTheseDocumentsAvailableInCollectionOfDatabase)
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should have only (?:this|these) (?:doc|docs|document|documents)(?: available)? `+eventuallyPattern+`[:]?$`, m.eventuallyHaveOnlyTheseDocumentsAvailableInCollectionOfDatabase)
+
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should have ([0-9]+) (?:doc|docs|document|documents)(?: available)? `+eventuallyPattern+`$`,
+		func(ctx context.Context, collectionName, databaseName string, count int64, timeout string) (context.Context, error) {
+			return m.eventuallyHaveNumberOfDocumentsAvailableInCollectionOfDatabase(ctx, count, collectionName, databaseName, timeout)
+		},
+	)
+
 	sc.Step(`found ([0-9]+) (?:doc|docs|document|documents) in the result$`, m.haveNumberOfDocumentsInSearchResult)
 	sc.Step(`there (?:is|are) ([0-9]+) (?:doc|docs|document|documents) in the result$`, m.haveNumberOfDocumentsInSearchResult)
 	sc.Step(`found (?:this|these) (?:doc|docs|document|documents) in the result[:]?$`, m.haveDocumentsInSearchResult)
 	sc.Step(`(?:this|these) (?:doc|docs|document|documents) (?:is|are) in the result[:]?$`, m.haveDocumentsInSearchResult)
+	sc.Step(`field "([^"]*)" of document ([0-9]+) in the result should be (.+)$`, m.haveFieldOfDocumentInSearchResult)
+	sc.Step(`field "([^"]*)" of document ([0-9]+) in the result should contain (.+)$`, m.haveFieldOfDocumentContainingInSearchResult)
+	sc.Step(`field "([^"]*)" of the first result should be (.+)$`,
+		func(ctx context.Context, field, expected string) (context.Context, error) {
+			return m.haveFieldOfDocumentInSearchResult(ctx, field, 1, expected)
+		},
+	)
+
+	sc.Step(`field "([^"]*)" of the first result should contain (.+)$`,
+		func(ctx context.Context, field, expected string) (context.Context, error) {
+			return m.haveFieldOfDocumentContainingInSearchResult(ctx, field, 1, expected)
+		},
+	)
+
+	sc.Step(`all (?:docs|documents) in the result should have field "([^"]*)" equal to (.+)$`, m.haveFieldInAllDocumentsOfSearchResult)
+	sc.Step(`(?:the )?result "([^"]*)" should have ([0-9]+) (?:doc|docs|document|documents)$`, m.haveNumberOfDocumentsInNamedResult)
+	sc.Step(`(?:the )?result "([^"]*)" should have (?:this|these) (?:doc|docs|document|documents)[:]?$`, m.haveDocumentsInNamedResult)
+	sc.Step(`(?:the )?result "([^"]*)" should equal result "([^"]*)"(?: except fields? "([^"]*)")?$`, m.haveNamedResultEqualToNamedResult)
+	sc.Step(`collection "([^"]*)" should exist$`,
+		func(ctx context.Context, collectionName string) (context.Context, error) {
+			return m.haveCollectionInDatabase(ctx, collectionName, defaultDatabase)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" should not exist$`,
+		func(ctx context.Context, collectionName string) (context.Context, error) {
+			return m.haveNoCollectionInDatabase(ctx, collectionName, defaultDatabase)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should exist$`, m.haveCollectionInDatabase)
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should not exist$`, m.haveNoCollectionInDatabase)
+
+	sc.Step(`collection "([^"]*)" should have (?:this index|these indexes)[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.haveTheseIndexesInCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`collection "([^"]*)" of database "([^"]*)" should have (?:this index|these indexes)[:]?$`, m.haveTheseIndexesInCollectionOfDatabase)
+
+	sc.Step(`found ([0-9]+) (?:doc|docs|document|documents) in the result `+eventuallyPattern+`$`, m.eventuallyHaveNumberOfDocumentsInSearchResult)
+	sc.Step(`there (?:is|are) ([0-9]+) (?:doc|docs|document|documents) in the result `+eventuallyPattern+`$`, m.eventuallyHaveNumberOfDocumentsInSearchResult)
+	sc.Step(`found (?:this|these) (?:doc|docs|document|documents) in the result `+eventuallyPattern+`[:]?$`, m.eventuallyHaveDocumentsInSearchResult)
+	sc.Step(`(?:this|these) (?:doc|docs|document|documents) (?:is|are) in the result `+eventuallyPattern+`[:]?$`, m.eventuallyHaveDocumentsInSearchResult)
+	sc.Step(`found (?:this|these) (?:doc|docs|document|documents) in the result in any order[:]?$`, m.haveDocumentsInAnyOrderInSearchResult)
+	sc.Step(`(?:this|these) (?:doc|docs|document|documents) (?:is|are) in the result in any order[:]?$`, m.haveDocumentsInAnyOrderInSearchResult)
+	sc.Step(`the result should contain (?:this|these) (?:doc|docs|document|documents)[:]?$`, m.containDocumentsInSearchResult)
+	sc.Step(`found (?:this row|these rows) in the result( in any order)?[:]?$`, m.haveRowsInSearchResult)
+	sc.Step(`(?:this row is|these rows are) in the result( in any order)?[:]?$`, m.haveRowsInSearchResult)
+
+	sc.Step(`([0-9]+) (?:doc|docs|document|documents) (?:is|are|was|were) (matched|modified|upserted|deleted)$`, m.haveNumberOfDocumentsAffectedByLastWrite)
+	sc.Step(`(?:this|these) (?:doc|docs|document|documents) should be rejected by collection "([^"]*)"[:]?$`,
+		func(ctx context.Context, collectionName string, data *godog.DocString) (context.Context, error) {
+			return m.haveDocumentsRejectedByCollectionOfDatabase(ctx, collectionName, defaultDatabase, data)
+		},
+	)
+
+	sc.Step(`(?:this|these) (?:doc|docs|document|documents) should be rejected by collection "([^"]*)" of database "([^"]*)"[:]?$`, m.haveDocumentsRejectedByCollectionOfDatabase)
+
+	sc.Step(`the write error code should be ([0-9]+)$`, m.haveWriteErrorCode)
+	sc.Step(`the write error message should contain "([^"]*)"$`, m.haveWriteErrorMessage)
 }
 
 func (m *Manager) getDatabase(dbName string) (*database, error) {
@@ -166,6 +499,11 @@ func (m *Manager) noDocumentsInCollectionOfDatabase(ctx context.Context, collect
 }
 
 func (m *Manager) theseDocumentsAreStoredInCollectionOfDatabase(ctx context.Context, collectionName, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
 	db, err := m.getDatabase(dbName)
 	if err != nil {
 		return ctx, err
@@ -179,172 +517,1304 @@ func (m *Manager) theseDocumentsAreStoredInCollectionOfDatabase(ctx context.Cont
 	return ctx, db.store(ctx, collectionName, docs)
 }
 
-func (m *Manager) theseDocumentsFromFileAreStoredInCollectionOfDatabase(ctx context.Context, filePath, collectionName, dbName string) (context.Context, error) {
+func (m *Manager) restoreDumpToCollectionOfDatabase(ctx context.Context, filePath, collectionName, dbName string) (context.Context, error) {
 	db, err := m.getDatabase(dbName)
 	if err != nil {
 		return ctx, err
 	}
 
-	data, err := os.ReadFile(path.Clean(filePath))
+	r, closer, err := openDumpFile(filePath)
 	if err != nil {
 		return ctx, err
 	}
 
-	docs, err := bytesToDocs(data)
+	defer closer.Close() // nolint: errcheck
+
+	ctx = contextWithCleanUps(ctx, dbName, collectionName)
+
+	metadata, err := readDumpMetadata(filePath)
 	if err != nil {
 		return ctx, err
 	}
 
-	return ctx, db.store(ctx, collectionName, docs)
+	if metadata != nil {
+		if err := db.restoreMetadata(ctx, collectionName, metadata); err != nil {
+			return ctx, err
+		}
+	}
+
+	// An empty collection is dumped to an empty file.
+	if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+		return ctx, nil
+	}
+
+	return ctx, db.storeAll(ctx, collectionName, &bsonReader{r: r, closer: closer}, false)
 }
 
-func (m *Manager) searchInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+func (m *Manager) restoreDumpArchiveToDatabase(ctx context.Context, filePath, dbName string) (context.Context, error) {
 	db, err := m.getDatabase(dbName)
 	if err != nil {
 		return ctx, err
 	}
 
-	filter, err := stringToBSOND(data)
+	r, closer, err := openDumpFile(filePath)
 	if err != nil {
-		return ctx, fmt.Errorf("failed to parse filter: %w", err)
+		return ctx, err
 	}
 
-	result, err := db.find(ctx, collectionName, filter, options.Find().SetLimit(0).SetSort(bson.D{{Key: "_id", Value: 1}}))
+	defer closer.Close() // nolint: errcheck
+
+	collections, err := db.restoreArchive(ctx, r)
+
+	// The collections are cleaned up after the scenario, even when they are partially restored.
+	ctx = contextWithCleanUps(ctx, dbName, collections...)
+
 	if err != nil {
-		return ctx, err
+		return ctx, fmt.Errorf("could not restore archive %q: %w", filePath, err)
 	}
 
-	return contextWithDocs(ctx, result), nil
+	return ctx, nil
 }
 
-func (m *Manager) noDocumentsAreAvailableInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string) (context.Context, error) {
+func (m *Manager) seedDatabaseFromDirectory(ctx context.Context, dbName, dirPath string) (context.Context, error) {
 	db, err := m.getDatabase(dbName)
 	if err != nil {
 		return ctx, err
 	}
 
-	count, err := db.count(ctx, collectionName, bson.D{})
+	plan, err := readSeedDirectory(dirPath)
 	if err != nil {
-		return ctx, err
+		return ctx, fmt.Errorf("could not read fixture directory %q: %w", dirPath, err)
 	}
 
-	if count > 0 {
-		return ctx, fmt.Errorf("collection %q has %d document(s), expected none", collectionName, count) // nolint: goerr113
+	for _, collection := range plan.Truncate {
+		if err := db.truncate(ctx, collection); err != nil {
+			return ctx, err
+		}
+	}
+
+	// The collections are registered before they are seeded, so that a partial seed is also cleaned up.
+	ctx = contextWithCleanUps(ctx, dbName, plan.collections()...)
+
+	for _, f := range plan.Files {
+		if err := seedCollection(ctx, db, f, m.fileResolver(&ctx)); err != nil {
+			return ctx, err
+		}
 	}
 
 	return ctx, nil
 }
 
-func (m *Manager) haveNumberOfDocumentsAvailableInCollectionOfDatabase(ctx context.Context, expected int64, collectionName string, dbName string) (context.Context, error) {
-	db, err := m.getDatabase(dbName)
+func (m *Manager) theseRowsAreStoredInCollectionOfDatabase(ctx context.Context, collectionName, dbName string, table *godog.Table) (context.Context, error) {
+	ctx, table, err := m.resolveTable(ctx, table)
 	if err != nil {
 		return ctx, err
 	}
 
-	actual, err := db.count(ctx, collectionName, bson.D{})
+	db, err := m.getDatabase(dbName)
 	if err != nil {
 		return ctx, err
 	}
 
-	if actual != expected {
-		return ctx, fmt.Errorf("collection %q has %d document(s), expected %d", collectionName, actual, expected) // nolint: goerr113
+	docs, err := tableToDocs(table)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse rows: %w", err)
 	}
 
-	return ctx, nil
+	return ctx, db.store(ctx, collectionName, docs)
 }
 
-func (m *Manager) haveOnlyTheseDocumentsAvailableInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+func (m *Manager) theseDocumentsFromFileAreStoredInCollectionOfDatabase(ctx context.Context, filePath, collectionName, dbName string) (context.Context, error) {
 	db, err := m.getDatabase(dbName)
 	if err != nil {
 		return ctx, err
 	}
 
-	expectedDocs, err := stringToDocs(data)
+	r, err := openDocsFile(filePath, m.fileResolver(&ctx))
 	if err != nil {
-		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+		return ctx, err
 	}
 
-	actualDocs, err := db.find(ctx, collectionName, bson.D{}, options.Find().SetLimit(0).SetSort(bson.D{{Key: "_id", Value: 1}}))
+	defer r.Close() // nolint: errcheck
+
+	err = db.storeAll(ctx, collectionName, r, false)
+
+	return ctx, err
+}
+
+func (m *Manager) storingDocumentsInCollectionOfDatabaseShouldFail(ctx context.Context, collectionName, dbName string, kind string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
 	if err != nil {
 		return ctx, err
 	}
 
-	expected, err := docsToExtJSON(expectedDocs)
+	db, err := m.getDatabase(dbName)
 	if err != nil {
-		return ctx, fmt.Errorf("failed to convert expected documents to JSON: %w", err)
+		return ctx, err
 	}
 
-	actual, err := docsToExtJSON(actualDocs)
+	docs, err := stringToDocs(data)
 	if err != nil {
-		return ctx, fmt.Errorf("failed to convert actual documents to JSON: %w", err)
+		return ctx, fmt.Errorf("failed to parse documents: %w", err)
 	}
 
-	return ctx, assertjson.FailNotEqual(expected, actual)
-}
+	if len(docs) == 0 {
+		return ctx, errors.New("failed to parse documents: no documents") // nolint: goerr113
+	}
 
-func (m *Manager) haveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(ctx context.Context, filePath string, collectionName string, dbName string) (context.Context, error) {
-	expected, err := os.ReadFile(path.Clean(filePath))
-	if err != nil {
-		return ctx, err
+	expected := "an error"
+	if kind != "" {
+		expected = kind + " error"
 	}
 
-	return m.haveOnlyTheseDocumentsAvailableInCollectionOfDatabase(ctx, collectionName, dbName, &godog.DocString{Content: string(expected)})
-}
+	err = db.store(ctx, collectionName, docs)
+	if err == nil {
+		return ctx, fmt.Errorf("documents are stored in collection %q, expected %s", collectionName, expected) // nolint: goerr113
+	}
 
-func (m *Manager) haveNumberOfDocumentsInSearchResult(ctx context.Context, expected int64) (context.Context, error) {
-	docs := docsFromContext(ctx)
-	if docs == nil {
-		//goland:noinspection GoErrorStringFormat
-		return ctx, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+	if !isServerWriteError(err) {
+		return ctx, fmt.Errorf("could not store documents in collection %q, expected %s from the server: %w", collectionName, expected, err)
 	}
 
-	actual := int64(len(docs))
+	e := classifyWriteError(err)
 
-	if actual != expected {
-		return ctx, fmt.Errorf("there are %d documents in the search result, expected %d", actual, expected) // nolint: goerr113
+	if kind != "" && e.Kind != kind {
+		return ctx, fmt.Errorf("expected %s, got %s error: %w", expected, e.Kind, err)
 	}
 
-	return ctx, nil
+	return contextWithWriteError(ctx, e), nil
 }
 
-func (m *Manager) haveDocumentsInSearchResult(ctx context.Context, data *godog.DocString) (context.Context, error) {
-	actualDocs := docsFromContext(ctx)
-	if actualDocs == nil {
-		//goland:noinspection GoErrorStringFormat
-		return ctx, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+func (m *Manager) updateDocumentsInCollectionOfDatabase(ctx context.Context, collectionName, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveQueryDocString(ctx, data)
+	if err != nil {
+		return ctx, err
 	}
 
-	expectedDocs, err := stringToDocs(data)
+	db, err := m.getDatabase(dbName)
 	if err != nil {
-		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+		return ctx, err
 	}
 
-	expected, err := docsToExtJSON(expectedDocs)
+	q, err := stringToUpdateQuery(data)
 	if err != nil {
-		return ctx, fmt.Errorf("failed to convert expected documents to JSON: %w", err)
+		return ctx, fmt.Errorf("failed to parse update: %w", err)
 	}
 
-	actual, err := docsToExtJSON(actualDocs)
+	result, err := db.update(ctx, collectionName, q.Filter, q.Update)
 	if err != nil {
-		return ctx, fmt.Errorf("failed to convert actual documents to JSON: %w", err)
+		return ctx, err
 	}
 
-	return ctx, assertjson.FailNotEqual(expected, actual)
+	return contextWithWriteResult(ctx, toWriteResult(result)), nil
 }
 
-// NewManager creates a new Manager.
-func NewManager(opts ...ManagerOption) *Manager {
-	m := &Manager{
-		databases: make(map[string]*database),
-	}
-
-	for _, opt := range opts {
-		opt.applyManagerOption(m)
+func (m *Manager) upsertDocumentsInCollectionOfDatabase(ctx context.Context, collectionName, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveQueryDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	q, err := stringToUpdateQuery(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse update: %w", err)
+	}
+
+	result, err := db.upsert(ctx, collectionName, q.Filter, q.Update)
+	if err != nil {
+		return ctx, err
+	}
+
+	return contextWithWriteResult(ctx, toWriteResult(result)), nil
+}
+
+func (m *Manager) deleteDocumentsFromCollectionOfDatabase(ctx context.Context, collectionName, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveQueryDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	filter, err := stringToBSOND(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse filter: %w", err)
+	}
+
+	deleted, err := db.delete(ctx, collectionName, filter)
+	if err != nil {
+		return ctx, err
+	}
+
+	return contextWithWriteResult(ctx, writeResult{Deleted: deleted}), nil
+}
+
+func (m *Manager) searchInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveQueryDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	q, err := stringToFindQuery(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse filter: %w", err)
+	}
+
+	result, err := db.find(ctx, collectionName, q.Filter, q.findOptions())
+	if err != nil {
+		return ctx, err
+	}
+
+	ctx = contextWithDocs(ctx, result)
+
+	return contextWithSearchQuery(ctx, searchQuery{Database: dbName, Collection: collectionName, Find: &q}), nil
+}
+
+func (m *Manager) runAggregationOnCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveQueryDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	pipeline, err := stringToPipeline(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse pipeline: %w", err)
+	}
+
+	result, err := db.aggregate(ctx, collectionName, pipeline)
+	if err != nil {
+		return ctx, err
+	}
+
+	ctx = contextWithDocs(ctx, result)
+
+	return contextWithSearchQuery(ctx, searchQuery{Database: dbName, Collection: collectionName, Pipeline: pipeline}), nil
+}
+
+func (m *Manager) searchInCollectionOfDatabaseAs(ctx context.Context, collectionName, dbName, name string, data *godog.DocString) (context.Context, error) {
+	ctx, err := m.searchInCollectionOfDatabase(ctx, collectionName, dbName, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	return contextWithNamedResult(ctx, name, docsFromContext(ctx)), nil
+}
+
+func (m *Manager) runAggregationOnCollectionOfDatabaseAs(ctx context.Context, collectionName, dbName, name string, data *godog.DocString) (context.Context, error) {
+	ctx, err := m.runAggregationOnCollectionOfDatabase(ctx, collectionName, dbName, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	return contextWithNamedResult(ctx, name, docsFromContext(ctx)), nil
+}
+
+func (m *Manager) applyValidatorToCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveQueryDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	v, err := stringToValidation(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse validator: %w", err)
+	}
+
+	return ctx, db.applyValidation(ctx, collectionName, v)
+}
+
+func (m *Manager) createIndexesInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	indexes, err := stringToIndexes(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse indexes: %w", err)
+	}
+
+	return ctx, db.createIndexes(ctx, collectionName, indexes)
+}
+
+func (m *Manager) dropIndexFromCollectionOfDatabase(ctx context.Context, indexName, collectionName string, dbName string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	return ctx, db.dropIndex(ctx, collectionName, indexName)
+}
+
+func (m *Manager) dropAllIndexesFromCollectionOfDatabase(ctx context.Context, collectionName string, dbName string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	return ctx, db.dropIndexes(ctx, collectionName)
+}
+
+func (m *Manager) createCollectionInDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveQueryDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	opts, err := stringToCollectionOptions(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse collection options: %w", err)
+	}
+
+	return ctx, db.createCollection(ctx, collectionName, opts.createCollectionOptions())
+}
+
+func (m *Manager) dropCollectionFromDatabase(ctx context.Context, collectionName string, dbName string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	return ctx, db.dropCollection(ctx, collectionName)
+}
+
+func (m *Manager) renameCollectionInDatabase(ctx context.Context, collectionName string, dbName string, newName string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	return ctx, db.renameCollection(ctx, collectionName, newName)
+}
+
+func (m *Manager) noDocumentsAreAvailableInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	count, err := db.count(ctx, collectionName, bson.D{})
+	if err != nil {
+		return ctx, err
+	}
+
+	if count > 0 {
+		return ctx, fmt.Errorf("collection %q has %d document(s), expected none", collectionName, count) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveNumberOfDocumentsAvailableInCollectionOfDatabase(ctx context.Context, expected int64, collectionName string, dbName string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	actual, err := db.count(ctx, collectionName, bson.D{})
+	if err != nil {
+		return ctx, err
+	}
+
+	if actual != expected {
+		return ctx, fmt.Errorf("collection %q has %d document(s), expected %d", collectionName, actual, expected) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveNumberOfDocumentsMatchingInCollectionOfDatabase(ctx context.Context, comparison string, expected int64, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveQueryDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	filter, err := stringToBSOND(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse filter: %w", err)
+	}
+
+	actual, err := db.count(ctx, collectionName, filter)
+	if err != nil {
+		return ctx, err
+	}
+
+	if !compareCount(comparison, actual, expected) {
+		return ctx, fmt.Errorf("collection %q has %d document(s) matching the query, expected %s", collectionName, actual, strings.TrimSpace(comparison+" "+strconv.FormatInt(expected, 10))) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveOnlyTheseDocumentsAvailableInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	return m.haveOnlyTheseDocumentsInCollectionOfDatabase(ctx, collectionName, dbName, data, false)
+}
+
+func (m *Manager) haveOnlyTheseDocumentsInAnyOrderAvailableInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	return m.haveOnlyTheseDocumentsInCollectionOfDatabase(ctx, collectionName, dbName, data, true)
+}
+
+func (m *Manager) haveOnlyTheseDocumentsInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString, anyOrder bool) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	expectedDocs, err := stringToDocs(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+	}
+
+	return ctx, m.haveOnlyDocumentsInCollection(ctx, db, collectionName, expectedDocs, anyOrder)
+}
+
+func (m *Manager) haveOnlyTheseRowsInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, order string, table *godog.Table) (context.Context, error) {
+	ctx, table, err := m.resolveTable(ctx, table)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	expectedDocs, err := tableToDocs(table)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected rows: %w", err)
+	}
+
+	return ctx, m.haveOnlyDocumentsInCollection(ctx, db, collectionName, expectedDocs, order != "")
+}
+
+func (m *Manager) containTheseDocumentsInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	expectedDocs, err := stringToDocs(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+	}
+
+	actualDocs, err := db.find(ctx, collectionName, bson.D{}, options.Find().SetLimit(0).SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return ctx, err
+	}
+
+	if err := containsDocuments(expectedDocs, actualDocs); err != nil {
+		return ctx, fmt.Errorf("collection %q does not contain the expected documents: %w", collectionName, err)
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(ctx context.Context, filePath string, collectionName string, dbName string) (context.Context, error) {
+	raw, data, err := readExpectedFile(filePath, m.updateExpectedFiles, m.fileResolver(&ctx))
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	return ctx, m.haveOnlyDocumentsOfFileInCollection(ctx, db, collectionName, filePath, raw, data)
+}
+
+func (m *Manager) haveDatabaseMatchingDirectory(ctx context.Context, dbName, dirPath, noOtherCollections string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	plan, err := readSeedDirectory(dirPath)
+	if err != nil {
+		return ctx, fmt.Errorf("could not read fixture directory %q: %w", dirPath, err)
+	}
+
+	var report strings.Builder
+
+	mismatches := 0
+
+	// All the collections are compared, so that the report has all the differences at once.
+	for _, f := range plan.Files {
+		if err := m.haveOnlyDocumentsFromFileInCollection(ctx, db, f.Collection, f.Path, m.fileResolver(&ctx)); err != nil {
+			mismatches++
+
+			_, _ = fmt.Fprintf(&report, "\ncollection %q:\n%s\n", f.Collection, err)
+		}
+	}
+
+	if noOtherCollections != "" {
+		others, err := otherCollectionsWithDocuments(ctx, db, plan.collections())
+		if err != nil {
+			return ctx, err
+		}
+
+		for _, collection := range others {
+			if m.updateExpectedFiles {
+				if err := m.haveOnlyDocumentsFromFileInCollection(ctx, db, collection, filepath.Join(dirPath, collection+".json"), nil); err != nil {
+					return ctx, err
+				}
+
+				continue
+			}
+
+			mismatches++
+
+			_, _ = fmt.Fprintf(&report, "\ncollection %q has documents but no file in the directory\n", collection)
+		}
+	}
+
+	if mismatches == 0 {
+		return ctx, nil
+	}
+
+	return ctx, fmt.Errorf("database %q does not match directory %q, %d collection(s) differ:\n%s", dbName, dirPath, mismatches, report.String()) // nolint: goerr113
+}
+
+func (m *Manager) haveCollectionInDatabase(ctx context.Context, collectionName string, dbName string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	exists, err := db.hasCollection(ctx, collectionName)
+	if err != nil {
+		return ctx, err
+	}
+
+	if !exists {
+		return ctx, fmt.Errorf("collection %q does not exist", collectionName) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveNoCollectionInDatabase(ctx context.Context, collectionName string, dbName string) (context.Context, error) {
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	exists, err := db.hasCollection(ctx, collectionName)
+	if err != nil {
+		return ctx, err
+	}
+
+	if exists {
+		return ctx, fmt.Errorf("collection %q exists", collectionName) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveTheseIndexesInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	expectedIndexes, err := stringToDocs(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected indexes: %w", err)
+	}
+
+	actualIndexes, err := db.listIndexes(ctx, collectionName)
+	if err != nil {
+		return ctx, err
+	}
+
+	// Remove the fields that are added by the server.
+	for i, index := range actualIndexes {
+		if actualIndexes[i], err = withoutFields(index, "v", "ns"); err != nil {
+			return ctx, fmt.Errorf("failed to normalize actual indexes: %w", err)
+		}
+	}
+
+	expected, err := docsToExtJSON(expectedIndexes)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to convert expected indexes to JSON: %w", err)
+	}
+
+	actual, err := docsToExtJSON(actualIndexes)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to convert actual indexes to JSON: %w", err)
+	}
+
+	return ctx, assertjson.FailNotEqual(expected, actual)
+}
+
+func (m *Manager) haveNumberOfDocumentsInSearchResult(ctx context.Context, expected int64) (context.Context, error) {
+	docs := docsFromContext(ctx)
+	if docs == nil {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+	}
+
+	actual := int64(len(docs))
+
+	if actual != expected {
+		return ctx, fmt.Errorf("there are %d documents in the search result, expected %d", actual, expected) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveDocumentsInSearchResult(ctx context.Context, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	actualDocs := docsFromContext(ctx)
+	if actualDocs == nil {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+	}
+
+	expectedDocs, err := stringToDocs(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+	}
+
+	return ctx, m.equalDocumentsInSearchResult(ctx, expectedDocs, actualDocs)
+}
+
+// equalDocumentsInSearchResult checks whether the search result has only the expected documents, in the same order
+// unless the documents of the searched database are compared in any order.
+func (m *Manager) equalDocumentsInSearchResult(ctx context.Context, expectedDocs, actualDocs []bsoncore.Document) error {
+	if q, ok := searchQueryFromContext(ctx); ok {
+		if db, err := m.getDatabase(q.Database); err == nil && db.anyOrder {
+			if err := equalDocumentsInAnyOrder(expectedDocs, actualDocs); err != nil {
+				return fmt.Errorf("the search result does not have the expected documents: %w", err)
+			}
+
+			return nil
+		}
+	}
+
+	return m.equalDocuments(expectedDocs, actualDocs)
+}
+
+func (m *Manager) haveRowsInSearchResult(ctx context.Context, order string, table *godog.Table) (context.Context, error) {
+	ctx, table, err := m.resolveTable(ctx, table)
+	if err != nil {
+		return ctx, err
+	}
+
+	actualDocs := docsFromContext(ctx)
+	if actualDocs == nil {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+	}
+
+	expectedDocs, err := tableToDocs(table)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected rows: %w", err)
+	}
+
+	if order == "" {
+		return ctx, m.equalDocumentsInSearchResult(ctx, expectedDocs, actualDocs)
+	}
+
+	if err := equalDocumentsInAnyOrder(expectedDocs, actualDocs); err != nil {
+		return ctx, fmt.Errorf("the search result does not have the expected documents: %w", err)
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveDocumentsInAnyOrderInSearchResult(ctx context.Context, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	actualDocs := docsFromContext(ctx)
+	if actualDocs == nil {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+	}
+
+	expectedDocs, err := stringToDocs(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+	}
+
+	if err := equalDocumentsInAnyOrder(expectedDocs, actualDocs); err != nil {
+		return ctx, fmt.Errorf("the search result does not have the expected documents: %w", err)
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) containDocumentsInSearchResult(ctx context.Context, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	actualDocs := docsFromContext(ctx)
+	if actualDocs == nil {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+	}
+
+	expectedDocs, err := stringToDocs(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+	}
+
+	if err := containsDocuments(expectedDocs, actualDocs); err != nil {
+		return ctx, fmt.Errorf("the search result does not contain the expected documents: %w", err)
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveNumberOfDocumentsAffectedByLastWrite(ctx context.Context, expected int64, kind string) (context.Context, error) {
+	result, ok := writeResultFromContext(ctx)
+	if !ok {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no write result is available, did you forget to update or delete documents?") // nolint: goerr113
+	}
+
+	var actual int64
+
+	switch kind {
+	case "matched":
+		actual = result.Matched
+	case "modified":
+		actual = result.Modified
+	case "upserted":
+		actual = result.Upserted
+	case "deleted":
+		actual = result.Deleted
+	default:
+		return ctx, fmt.Errorf("unknown write result %q", kind) // nolint: goerr113
+	}
+
+	if actual != expected {
+		return ctx, fmt.Errorf("%d document(s) %s, expected %d", actual, kind, expected) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) eventuallyHaveNumberOfDocumentsAvailableInCollectionOfDatabase(ctx context.Context, expected int64, collectionName string, dbName string, timeout string) (context.Context, error) {
+	if _, err := m.getDatabase(dbName); err != nil {
+		return ctx, err
+	}
+
+	return m.eventually(ctx, timeout, func(ctx context.Context) (context.Context, error) {
+		return m.haveNumberOfDocumentsAvailableInCollectionOfDatabase(ctx, expected, collectionName, dbName)
+	})
+}
+
+func (m *Manager) eventuallyHaveOnlyTheseDocumentsAvailableInCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, timeout string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	if _, err := m.getDatabase(dbName); err != nil {
+		return ctx, err
+	}
+
+	if _, err := stringToDocs(data); err != nil {
+		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+	}
+
+	return m.eventually(ctx, timeout, func(ctx context.Context) (context.Context, error) {
+		return m.haveOnlyTheseDocumentsAvailableInCollectionOfDatabase(ctx, collectionName, dbName, data)
+	})
+}
+
+func (m *Manager) eventuallyHaveNumberOfDocumentsInSearchResult(ctx context.Context, expected int64, timeout string) (context.Context, error) {
+	q, ok := searchQueryFromContext(ctx)
+	if !ok {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no search is available to be refreshed, did you forget to search?") // nolint: goerr113
+	}
+
+	return m.eventually(ctx, timeout, func(ctx context.Context) (context.Context, error) {
+		ctx, err := m.refreshSearchResult(ctx, q)
+		if err != nil {
+			return ctx, err
+		}
+
+		return m.haveNumberOfDocumentsInSearchResult(ctx, expected)
+	})
+}
+
+func (m *Manager) eventuallyHaveDocumentsInSearchResult(ctx context.Context, timeout string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	q, ok := searchQueryFromContext(ctx)
+	if !ok {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no search is available to be refreshed, did you forget to search?") // nolint: goerr113
+	}
+
+	if _, err := stringToDocs(data); err != nil {
+		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+	}
+
+	return m.eventually(ctx, timeout, func(ctx context.Context) (context.Context, error) {
+		ctx, err := m.refreshSearchResult(ctx, q)
+		if err != nil {
+			return ctx, err
+		}
+
+		return m.haveDocumentsInSearchResult(ctx, data)
+	})
+}
+
+// refreshSearchResult runs the search again and replaces the search result.
+func (m *Manager) refreshSearchResult(ctx context.Context, q searchQuery) (context.Context, error) {
+	db, err := m.getDatabase(q.Database)
+	if err != nil {
+		return ctx, err
+	}
+
+	var result []bsoncore.Document
+
+	if q.Find != nil {
+		result, err = db.find(ctx, q.Collection, q.Find.Filter, q.Find.findOptions())
+	} else {
+		result, err = db.aggregate(ctx, q.Collection, q.Pipeline)
+	}
+
+	if err != nil {
+		return ctx, err
+	}
+
+	return contextWithDocs(ctx, result), nil
+}
+
+// eventually runs the step until it succeeds or the timeout is reached. On timeout, the error of the last attempt is
+// returned. An empty timeout means the default poll timeout of the manager.
+func (m *Manager) eventually(ctx context.Context, timeout string, step func(ctx context.Context) (context.Context, error)) (context.Context, error) {
+	wait := m.pollTimeout
+
+	if timeout != "" {
+		var err error
+
+		if wait, err = time.ParseDuration(timeout); err != nil {
+			return ctx, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	deadline := time.Now().Add(wait)
+
+	for {
+		result, err := step(ctx)
+		if err == nil {
+			return result, nil
+		}
+
+		if time.Now().Add(m.pollInterval).After(deadline) {
+			return ctx, fmt.Errorf("timed out after %s: %w", wait, err)
+		}
+
+		timer := time.NewTimer(m.pollInterval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx, ctx.Err()
+
+		case <-timer.C:
+		}
+	}
+}
+
+func (m *Manager) haveDocumentsRejectedByCollectionOfDatabase(ctx context.Context, collectionName string, dbName string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	db, err := m.getDatabase(dbName)
+	if err != nil {
+		return ctx, err
+	}
+
+	docs, err := stringToDocs(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse documents: %w", err)
+	}
+
+	err = db.store(ctx, collectionName, docs)
+	if err == nil {
+		return ctx, fmt.Errorf("documents are accepted by collection %q, expected a document validation error", collectionName) // nolint: goerr113
+	}
+
+	if !isServerWriteError(err) {
+		return ctx, fmt.Errorf("could not store documents in collection %q, expected a document validation error from the server: %w", collectionName, err)
+	}
+
+	e := classifyWriteError(err)
+
+	if e.Kind != writeErrorValidation {
+		details := ""
+		if len(e.Details) > 0 {
+			details = fmt.Sprintf(" (error details: %s)", e.Details)
+		}
+
+		return ctx, fmt.Errorf("documents are rejected by collection %q with %s error%s, expected a document validation error: %w", collectionName, e.Kind, details, err)
+	}
+
+	return contextWithWriteError(ctx, e), nil
+}
+
+func (m *Manager) rememberFieldOfDocumentInSearchResult(ctx context.Context, field string, index int, name string) (context.Context, error) {
+	v, err := fieldOfDocumentInSearchResult(ctx, field, index)
+	if err != nil {
+		return ctx, err
+	}
+
+	var value interface{}
+
+	if err := (bson.RawValue{Type: v.Type, Value: v.Data}).Unmarshal(&value); err != nil {
+		return ctx, fmt.Errorf("could not read field %q of document #%d of the result: %w", field, index, err)
+	}
+
+	return m.variables.SetVariable(ctx, name, value), nil
+}
+
+func (m *Manager) haveFieldOfDocumentInSearchResult(ctx context.Context, field string, index int, expected string) (context.Context, error) {
+	ctx, expectedValue, err := m.expectedFieldValue(ctx, expected)
+	if err != nil {
+		return ctx, err
+	}
+
+	actual, err := fieldOfDocumentInSearchResult(ctx, field, index)
+	if err != nil {
+		return ctx, err
+	}
+
+	if !matchFieldValue(expectedValue, actual) {
+		return ctx, fmt.Errorf("field %q of document #%d of the result is %s, expected %s", field, index, valueText(actual), valueText(expectedValue)) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveFieldOfDocumentContainingInSearchResult(ctx context.Context, field string, index int, expected string) (context.Context, error) {
+	ctx, expectedValue, err := m.expectedFieldValue(ctx, expected)
+	if err != nil {
+		return ctx, err
+	}
+
+	actual, err := fieldOfDocumentInSearchResult(ctx, field, index)
+	if err != nil {
+		return ctx, err
+	}
+
+	if !containFieldValue(expectedValue, actual) {
+		return ctx, fmt.Errorf("field %q of document #%d of the result is %s, expected it to contain %s", field, index, valueText(actual), valueText(expectedValue)) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveFieldInAllDocumentsOfSearchResult(ctx context.Context, field string, expected string) (context.Context, error) {
+	ctx, expectedValue, err := m.expectedFieldValue(ctx, expected)
+	if err != nil {
+		return ctx, err
+	}
+
+	docs := docsFromContext(ctx)
+	if docs == nil {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+	}
+
+	if len(docs) == 0 {
+		return ctx, fmt.Errorf("there are no documents in the result to have field %q", field) // nolint: goerr113
+	}
+
+	var report strings.Builder
+
+	mismatches := 0
+
+	for i, doc := range docs {
+		actual, err := doc.LookupErr(strings.Split(field, ".")...)
+
+		switch {
+		case err != nil:
+			_, _ = fmt.Fprintf(&report, "  document #%d: no field\n", i+1)
+
+		case !matchFieldValue(expectedValue, actual):
+			_, _ = fmt.Fprintf(&report, "  document #%d: %s\n", i+1, valueText(actual))
+
+		default:
+			continue
+		}
+
+		mismatches++
+	}
+
+	if mismatches > 0 {
+		return ctx, fmt.Errorf("field %q of %d document(s) of the result is not %s:\n%s", field, mismatches, valueText(expectedValue), report.String()) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveNumberOfDocumentsInNamedResult(ctx context.Context, name string, expected int64) (context.Context, error) {
+	docs, err := namedResult(ctx, name)
+	if err != nil {
+		return ctx, err
+	}
+
+	if actual := int64(len(docs)); actual != expected {
+		return ctx, fmt.Errorf("there are %d documents in the result %q, expected %d", actual, name, expected) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveDocumentsInNamedResult(ctx context.Context, name string, data *godog.DocString) (context.Context, error) {
+	ctx, data, err := m.resolveDocString(ctx, data)
+	if err != nil {
+		return ctx, err
+	}
+
+	actualDocs, err := namedResult(ctx, name)
+	if err != nil {
+		return ctx, err
+	}
+
+	expectedDocs, err := stringToDocs(data)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to parse expected documents: %w", err)
+	}
+
+	if err := m.equalDocuments(expectedDocs, actualDocs); err != nil {
+		return ctx, fmt.Errorf("the result %q does not have the expected documents: %w", name, err)
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveNamedResultEqualToNamedResult(ctx context.Context, name, otherName, exceptFields string) (context.Context, error) {
+	actualDocs, err := namedResult(ctx, name)
+	if err != nil {
+		return ctx, err
+	}
+
+	expectedDocs, err := namedResult(ctx, otherName)
+	if err != nil {
+		return ctx, err
+	}
+
+	var fields []string
+
+	for _, f := range strings.Split(exceptFields, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+
+	if len(fields) > 0 {
+		if actualDocs, err = docsWithoutFieldPaths(actualDocs, fields...); err != nil {
+			return ctx, fmt.Errorf("failed to remove fields from the result %q: %w", name, err)
+		}
+
+		if expectedDocs, err = docsWithoutFieldPaths(expectedDocs, fields...); err != nil {
+			return ctx, fmt.Errorf("failed to remove fields from the result %q: %w", otherName, err)
+		}
+	}
+
+	if err := m.equalDocuments(expectedDocs, actualDocs); err != nil {
+		return ctx, fmt.Errorf("the result %q does not equal the result %q: %w", name, otherName, err)
+	}
+
+	return ctx, nil
+}
+
+// expectedFieldValue parses the expected value of a field, in ExtJSON like "City 2", 30 or {"$oid": "..."}, after the
+// variables and the generator expressions are resolved.
+func (m *Manager) expectedFieldValue(ctx context.Context, text string) (context.Context, bsoncore.Value, error) {
+	ctx, data, err := m.resolveDocString(ctx, &godog.DocString{Content: `{"v": ` + text + `}`})
+	if err != nil {
+		return ctx, bsoncore.Value{}, err
+	}
+
+	var doc bson.Raw
+
+	if err := bson.UnmarshalExtJSON([]byte(data.Content), false, &doc); err != nil {
+		return ctx, bsoncore.Value{}, fmt.Errorf("failed to parse expected value %s: %w", text, err)
+	}
+
+	return ctx, bsoncore.Document(doc).Lookup("v"), nil
+}
+
+func (m *Manager) haveWriteErrorCode(ctx context.Context, expected int) (context.Context, error) {
+	e, ok := writeErrorFromContext(ctx)
+	if !ok {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no write error is available, did you forget to store documents that should fail?") // nolint: goerr113
+	}
+
+	if e.Code != expected {
+		return ctx, fmt.Errorf("the write error code is %d, expected %d: %s", e.Code, expected, e.Message) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+func (m *Manager) haveWriteErrorMessage(ctx context.Context, expected string) (context.Context, error) {
+	e, ok := writeErrorFromContext(ctx)
+	if !ok {
+		//goland:noinspection GoErrorStringFormat
+		return ctx, fmt.Errorf("no write error is available, did you forget to store documents that should fail?") // nolint: goerr113
+	}
+
+	if !strings.Contains(e.Message, expected) {
+		return ctx, fmt.Errorf("the write error message %q does not contain %q", e.Message, expected) // nolint: goerr113
+	}
+
+	return ctx, nil
+}
+
+// compareCount compares the actual count with the expected count, an empty comparison means equality.
+func compareCount(comparison string, actual, expected int64) bool {
+	switch comparison {
+	case "at least":
+		return actual >= expected
+	case "at most":
+		return actual <= expected
+	case "more than":
+		return actual > expected
+	case "less than", "fewer than":
+		return actual < expected
+	default:
+		return actual == expected
+	}
+}
+
+func toWriteResult(r *mongo.UpdateResult) writeResult {
+	return writeResult{
+		Matched:  r.MatchedCount,
+		Modified: r.ModifiedCount,
+		Upserted: r.UpsertedCount,
+	}
+}
+
+// NewManager creates a new Manager.
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{
+		databases:    make(map[string]*database),
+		pollInterval: defaultPollInterval,
+		pollTimeout:  defaultPollTimeout,
+		variables:    contextVariableStore{},
+	}
+
+	m.updateExpectedFiles, _ = strconv.ParseBool(os.Getenv(updateExpectedFileEnv)) // nolint: errcheck
+
+	for _, opt := range opts {
+		opt.applyManagerOption(m)
 	}
 
 	return m
 }
 
+// fieldOfDocumentInSearchResult returns the value of the field of the document of the search result. The field is a
+// dotted path, and the documents are numbered from 1.
+func fieldOfDocumentInSearchResult(ctx context.Context, field string, index int) (bsoncore.Value, error) {
+	docs := docsFromContext(ctx)
+	if docs == nil {
+		//goland:noinspection GoErrorStringFormat
+		return bsoncore.Value{}, fmt.Errorf("no documents are available in the search result, did you forget to search?") // nolint: goerr113
+	}
+
+	if index < 1 || index > len(docs) {
+		return bsoncore.Value{}, fmt.Errorf("there is no document #%d in the result of %d document(s)", index, len(docs)) // nolint: goerr113
+	}
+
+	v, err := docs[index-1].LookupErr(strings.Split(field, ".")...)
+	if err != nil {
+		return bsoncore.Value{}, fmt.Errorf("field %q is not in document #%d of the result", field, index) // nolint: goerr113
+	}
+
+	return v, nil
+}
+
+// namedResult returns the documents of the search that is kept under the name.
+func namedResult(ctx context.Context, name string) ([]bsoncore.Document, error) {
+	docs, ok := namedResultFromContext(ctx, name)
+	if !ok {
+		//goland:noinspection GoErrorStringFormat
+		return nil, fmt.Errorf("no result %q is available, did you forget to search as %q?", name, name) // nolint: goerr113
+	}
+
+	return docs, nil
+}
+
 // WithDefaultDatabase sets the default database of the manager.
 func WithDefaultDatabase(db *mongo.Database, opts ...DatabaseOption) ManagerOption {
 	return managerOptionFunc(func(m *Manager) {
@@ -358,3 +1828,234 @@ func WithDatabase(name string, db *mongo.Database, opts ...DatabaseOption) Manag
 		m.databases[name] = newDatabase(db, opts...)
 	})
 }
+
+// WithPollInterval sets the interval between the attempts of the eventual assertions. A non-positive interval is
+// replaced by the default one, so that the server is not polled in a busy loop.
+func WithPollInterval(interval time.Duration) ManagerOption {
+	return managerOptionFunc(func(m *Manager) {
+		if interval <= 0 {
+			interval = defaultPollInterval
+		}
+
+		m.pollInterval = interval
+	})
+}
+
+// WithPollTimeout sets the default timeout of the eventual assertions.
+func WithPollTimeout(timeout time.Duration) ManagerOption {
+	return managerOptionFunc(func(m *Manager) {
+		m.pollTimeout = timeout
+	})
+}
+
+// seedCollection stores the documents of a file of a fixture directory in its collection.
+func seedCollection(ctx context.Context, db *database, f seedFile, resolve docStringResolver) error {
+	r, err := openDocsFile(f.Path, resolve)
+	if err != nil {
+		return fmt.Errorf("could not read documents from %q: %w", f.Path, err)
+	}
+
+	defer r.Close() // nolint: errcheck
+
+	// An empty file has no documents to seed.
+	return db.storeAll(ctx, f.Collection, r, true)
+}
+
+// WithVariableStore sets the store of the variables of the scenarios, to share them with the steps of other libraries.
+// By default, the variables are in the context of the scenario, see ContextWithVariable and VariableFromContext.
+func WithVariableStore(store VariableStore) ManagerOption {
+	return managerOptionFunc(func(m *Manager) {
+		m.variables = store
+	})
+}
+
+// WithExpectedFilesUpdate rewrites the files of expected documents with the actual documents, instead of failing when
+// they do not match. It is also enabled by the MONGOSTEPS_UPDATE_EXPECTED_FILES environment variable.
+func WithExpectedFilesUpdate() ManagerOption {
+	return managerOptionFunc(func(m *Manager) {
+		m.updateExpectedFiles = true
+	})
+}
+
+// WithDiffFormat sets the format of the report of the documents that are not equal, DiffFormatJSON by default.
+func WithDiffFormat(format DiffFormat) ManagerOption {
+	return managerOptionFunc(func(m *Manager) {
+		m.diffFormat = format
+	})
+}
+
+// haveOnlyDocumentsInCollection checks whether the collection has only the expected documents, in the order of their
+// _id unless the order does not matter.
+func (m *Manager) haveOnlyDocumentsInCollection(ctx context.Context, db *database, collectionName string, expectedDocs []bsoncore.Document, anyOrder bool) error {
+	actualDocs, err := findAllDocuments(ctx, db, collectionName)
+	if err != nil {
+		return err
+	}
+
+	return m.equalDocumentsInCollection(db, collectionName, expectedDocs, actualDocs, anyOrder)
+}
+
+// findAllDocuments returns all the documents in the collection, in the order of their _id.
+func findAllDocuments(ctx context.Context, db *database, collectionName string) ([]bsoncore.Document, error) {
+	return db.find(ctx, collectionName, bson.D{}, options.Find().SetLimit(0).SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+// equalDocumentsInCollection checks whether the expected and actual documents of the collection are equal, in the same
+// order unless the order does not matter.
+func (m *Manager) equalDocumentsInCollection(db *database, collectionName string, expectedDocs, actualDocs []bsoncore.Document, anyOrder bool) error {
+	if anyOrder || db.anyOrder {
+		if err := equalDocumentsInAnyOrder(expectedDocs, actualDocs); err != nil {
+			return fmt.Errorf("collection %q does not have the expected documents: %w", collectionName, err)
+		}
+
+		return nil
+	}
+
+	return m.equalDocuments(expectedDocs, actualDocs)
+}
+
+// haveOnlyDocumentsFromFileInCollection checks whether the collection has only the documents of the file.
+func (m *Manager) haveOnlyDocumentsFromFileInCollection(ctx context.Context, db *database, collectionName, filePath string, resolve docStringResolver) error {
+	raw, data, err := readExpectedFile(filePath, m.updateExpectedFiles, resolve)
+	if err != nil {
+		return err
+	}
+
+	return m.haveOnlyDocumentsOfFileInCollection(ctx, db, collectionName, filePath, raw, data)
+}
+
+// readExpectedFile reads a file of expected documents, and returns its raw content and its content resolved unless the
+// resolver is nil. When the expected files are updated, a file that does not exist has no documents.
+func readExpectedFile(filePath string, update bool, resolve docStringResolver) (*godog.DocString, *godog.DocString, error) {
+	raw, err := fileToDocString(filePath)
+	if update && errors.Is(err, os.ErrNotExist) {
+		raw = &godog.DocString{MediaType: docsFileMediaType(filePath)}
+
+		return raw, raw, nil
+	}
+
+	if err != nil || resolve == nil {
+		return raw, raw, err
+	}
+
+	data, err := resolve(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return raw, data, nil
+}
+
+// haveOnlyDocumentsOfFileInCollection checks whether the collection has only the documents of the file. When the
+// expected files are updated, a file that does not match is written with the actual documents, and the raw variables
+// and generated values of the file are kept where their resolved values still match.
+func (m *Manager) haveOnlyDocumentsOfFileInCollection(ctx context.Context, db *database, collectionName, filePath string, raw, data *godog.DocString) error {
+	var (
+		expectedDocs []bsoncore.Document
+		err          error
+	)
+
+	if data.Content != "" || !m.updateExpectedFiles {
+		if expectedDocs, err = stringToDocs(data); err != nil {
+			return fmt.Errorf("failed to parse expected documents: %w", err)
+		}
+	}
+
+	actualDocs, err := findAllDocuments(ctx, db, collectionName)
+	if err != nil {
+		return err
+	}
+
+	if err := m.equalDocumentsInCollection(db, collectionName, expectedDocs, actualDocs, false); err == nil || !m.updateExpectedFiles {
+		return err
+	}
+
+	rawDocs, err := rawExpectedDocs(raw, data, expectedDocs)
+	if err != nil {
+		return fmt.Errorf("could not update %q without losing its variables or generated values, update it manually: %w", filePath, err)
+	}
+
+	return updateExpectedFile(filePath, docsFileMediaType(filePath), rawDocs, expectedDocs, actualDocs)
+}
+
+// rawExpectedDocs parses the raw content of a file of expected documents, which must have the same documents as the
+// resolved content.
+func rawExpectedDocs(raw, data *godog.DocString, expectedDocs []bsoncore.Document) ([]bsoncore.Document, error) {
+	if raw.MediaType == data.MediaType && raw.Content == data.Content {
+		return expectedDocs, nil
+	}
+
+	rawDocs, err := stringToDocs(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rawDocs) != len(expectedDocs) {
+		return nil, fmt.Errorf("the file has %d raw document(s) and %d resolved document(s)", len(rawDocs), len(expectedDocs)) // nolint: goerr113
+	}
+
+	return rawDocs, nil
+}
+
+// otherCollectionsWithDocuments returns the collections that have documents, except the given ones. The empty
+// collections are left out because the clean up truncates the collections instead of dropping them.
+func otherCollectionsWithDocuments(ctx context.Context, db *database, collections []string) ([]string, error) {
+	names, err := db.listCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, name := range names {
+		if containsString(collections, name) {
+			continue
+		}
+
+		count, err := db.count(ctx, name, bson.D{})
+		if err != nil {
+			return nil, err
+		}
+
+		if count > 0 {
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+// equalDocuments checks whether the expected and actual documents are equal, in the same order. The differences are
+// reported in the diff format of the manager.
+func (m *Manager) equalDocuments(expectedDocs, actualDocs []bsoncore.Document) error {
+	switch m.diffFormat {
+	case DiffFormatReport:
+		return reportDiff(expectedDocs, actualDocs)
+
+	case DiffFormatText:
+		return textDiff(expectedDocs, actualDocs)
+	}
+
+	// The matchers are evaluated on the BSON values, the JSON comparison only knows <ignore-diff>.
+	matchedDocs := make([]bsoncore.Document, len(expectedDocs))
+
+	for i, doc := range expectedDocs {
+		matchedDocs[i] = doc
+
+		if i < len(actualDocs) {
+			matchedDocs[i] = applyMatchers(doc, actualDocs[i])
+		}
+	}
+
+	expected, err := docsToExtJSON(matchedDocs)
+	if err != nil {
+		return fmt.Errorf("failed to convert expected documents to JSON: %w", err)
+	}
+
+	actual, err := docsToExtJSON(actualDocs)
+	if err != nil {
+		return fmt.Errorf("failed to convert actual documents to JSON: %w", err)
+	}
+
+	return assertjson.FailNotEqual(expected, actual)
+}
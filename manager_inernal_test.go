@@ -5,15 +5,28 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/stretchr/testify/assert"
+	"github.com/swaggest/assertjson"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 )
 
+func TestWithPollInterval(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 10*time.Millisecond, NewManager(WithPollInterval(10*time.Millisecond)).pollInterval)
+	assert.Equal(t, defaultPollInterval, NewManager(WithPollInterval(0)).pollInterval)
+	assert.Equal(t, defaultPollInterval, NewManager(WithPollInterval(-time.Second)).pollInterval)
+}
+
 func TestManager_NoDocumentsInCollectionOfDatabase(t *testing.T) {
 	t.Parallel()
 
@@ -109,6 +122,12 @@ func TestManager_TheseDocumentsAreStoredInCollectionOfDatabase(t *testing.T) {
 			data:     &godog.DocString{Content: `[{"message": "hello world"}]`},
 			result:   1,
 		},
+		{
+			scenario: "success with yaml",
+			database: defaultDatabase,
+			data:     &godog.DocString{MediaType: "yaml", Content: "- message: hello world"},
+			result:   1,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -134,6 +153,79 @@ func TestManager_TheseDocumentsAreStoredInCollectionOfDatabase(t *testing.T) {
 	}
 }
 
+func TestManager_TheseRowsAreStoredInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		table           *godog.Table
+		result          int
+		expectedCommand string
+		expectedError   string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "no table",
+			database:      defaultDatabase,
+			expectedError: `failed to parse rows: table is empty`,
+		},
+		{
+			scenario:      "store error",
+			database:      defaultDatabase,
+			table:         newTable([]string{"name"}, []string{"John Doe"}),
+			expectedError: `could not insert documents into collection "customer": command failed`,
+		},
+		{
+			scenario: "success",
+			database: defaultDatabase,
+			table: newTable(
+				[]string{"_id", "name", "age", "address.city"},
+				[]string{"oid:6250053966df8910f804c3a7", "John Doe", "30", "City 1"},
+			),
+			result: 1,
+			expectedCommand: `{
+				"insert": "customer",
+				"ordered": true,
+				"documents": [
+					{"_id": {"$oid": "6250053966df8910f804c3a7"}, "name": "John Doe", "age": {"$numberInt": "30"}, "address": {"city": "City 1"}}
+				]
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(bson.D{{Key: "ok", Value: tc.result}})
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.theseRowsAreStoredInCollectionOfDatabase(context.Background(), "customer", tc.database, tc.table)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+
+				actual, err := withoutFields(bsoncore.Document(t.GetStartedEvent().Command), "$db", "lsid", "$readPreference", "txnNumber", "writeConcern")
+				assert.NoError(t, err)
+
+				assertjson.Equal(t, []byte(tc.expectedCommand), []byte(actual.String()))
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
 func TestManager_TheseDocumentsFromFileAreStoredInCollectionOfDatabase(t *testing.T) {
 	t.Parallel()
 
@@ -173,12 +265,37 @@ func TestManager_TheseDocumentsFromFileAreStoredInCollectionOfDatabase(t *testin
 			filePath:      "resources/fixtures/empty.json",
 			expectedError: `could not insert documents into collection "customer": must provide at least one element in input slice`,
 		},
+		{
+			scenario:      "malformed yaml",
+			database:      defaultDatabase,
+			filePath:      "resources/fixtures/malformed.yaml",
+			expectedError: `error unmarshaling yaml: yaml: line 1: did not find expected ',' or ']'`,
+		},
 		{
 			scenario: "success",
 			database: defaultDatabase,
 			filePath: "resources/fixtures/customers.json",
 			result:   1,
 		},
+		{
+			scenario: "success with yaml",
+			database: defaultDatabase,
+			filePath: "resources/fixtures/customers.yaml",
+			result:   1,
+		},
+		{
+			scenario:      "malformed ndjson",
+			database:      defaultDatabase,
+			filePath:      "resources/fixtures/malformed.ndjson",
+			result:        1,
+			expectedError: `could not read documents for collection "customer": line 2: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario: "success with ndjson",
+			database: defaultDatabase,
+			filePath: "resources/fixtures/customers.ndjson",
+			result:   1,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -204,45 +321,54 @@ func TestManager_TheseDocumentsFromFileAreStoredInCollectionOfDatabase(t *testin
 	}
 }
 
-func TestManager_SearchInCollectionOfDatabase(t *testing.T) {
+func TestManager_RestoreDumpToCollectionOfDatabase(t *testing.T) {
 	t.Parallel()
 
-	docs := mustParseDocs(readFixtures("resources/fixtures/customers.json"))
+	emptyDump := filepath.Join(t.TempDir(), "customer.bson")
+	assert.NoError(t, os.WriteFile(emptyDump, nil, 0o600))
 
 	testCases := []struct {
-		scenario        string
-		database        string
-		filter          *godog.DocString
-		result          []bson.D
-		expectedContext context.Context // nolint: containedctx
-		expectedError   string
+		scenario         string
+		database         string
+		filePath         string
+		results          []bson.D
+		expectedCommands []string
+		expectedCleanUps []string
+		expectedError    string
 	}{
 		{
-			scenario:        "missing database",
-			database:        "other",
-			expectedContext: context.Background(),
-			expectedError:   `mongo database "other" is not registered to the manager`,
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
 		},
 		{
-			scenario:        "could not parse filter",
-			database:        defaultDatabase,
-			filter:          &godog.DocString{Content: `malformed`},
-			expectedContext: context.Background(),
-			expectedError:   `failed to parse filter: error unmarshaling extjson: invalid JSON input`,
+			scenario:      "file not found",
+			database:      defaultDatabase,
+			filePath:      "resources/fixtures/dump/unknown.bson",
+			expectedError: `open resources/fixtures/dump/unknown.bson: no such file or directory`,
 		},
 		{
-			scenario:        "find error",
-			database:        defaultDatabase,
-			filter:          &godog.DocString{Content: `{}`},
-			result:          []bson.D{{{Key: "ok", Value: 0}}},
-			expectedContext: context.Background(),
-			expectedError:   `could not find documents in collection "customer": command failed`,
+			scenario:         "create indexes error",
+			database:         defaultDatabase,
+			filePath:         "resources/fixtures/dump/customer.bson",
+			results:          []bson.D{{{Key: "ok", Value: 0}}},
+			expectedCleanUps: []string{"customer"},
+			expectedError:    `could not create indexes in collection "customer": command failed`,
 		},
 		{
-			scenario:        "success without filter",
-			database:        defaultDatabase,
-			result:          createDocsResponse("db", "customer", docs),
-			expectedContext: contextWithDocs(context.Background(), docs),
+			scenario:         "empty dump",
+			database:         defaultDatabase,
+			filePath:         emptyDump,
+			expectedCommands: []string{},
+			expectedCleanUps: []string{"customer"},
+		},
+		{
+			scenario:         "success",
+			database:         defaultDatabase,
+			filePath:         "resources/fixtures/dump/customer.bson",
+			results:          []bson.D{mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse()},
+			expectedCommands: []string{"createIndexes", "insert"},
+			expectedCleanUps: []string{"customer"},
 		},
 	}
 
@@ -254,31 +380,43 @@ func TestManager_SearchInCollectionOfDatabase(t *testing.T) {
 		mt.Run(tc.scenario, func(t *mtest.T) {
 			t.Parallel()
 
-			t.AddMockResponses(tc.result...)
+			t.AddMockResponses(tc.results...)
 
 			m := NewManager(WithDefaultDatabase(t.DB))
 
-			ctx, err := m.searchInCollectionOfDatabase(context.Background(), "customer", tc.database, tc.filter)
+			ctx, err := m.restoreDumpToCollectionOfDatabase(context.Background(), tc.filePath, "customer", tc.database)
 
-			assert.Equal(t, tc.expectedContext, ctx)
+			assert.Equal(t, tc.expectedCleanUps, cleanUpsFromContext(ctx, defaultDatabase))
 
-			if tc.expectedError == "" {
-				assert.NoError(t, err)
-			} else {
+			if tc.expectedError != "" {
 				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			actualCommands := make([]string, 0)
+
+			for _, e := range t.GetAllStartedEvents() {
+				actualCommands = append(actualCommands, e.CommandName)
 			}
+
+			assert.Equal(t, tc.expectedCommands, actualCommands)
 		})
 	}
 }
 
-func TestManager_NoDocumentsAreAvailableInCollectionOfDatabase(t *testing.T) {
+func TestManager_RestoreDumpArchiveToDatabase(t *testing.T) {
 	t.Parallel()
 
 	testCases := []struct {
-		scenario      string
-		database      string
-		result        []bson.D
-		expectedError string
+		scenario         string
+		database         string
+		filePath         string
+		results          []bson.D
+		expectedCleanUps []string
+		expectedError    string
 	}{
 		{
 			scenario:      "missing database",
@@ -286,21 +424,38 @@ func TestManager_NoDocumentsAreAvailableInCollectionOfDatabase(t *testing.T) {
 			expectedError: `mongo database "other" is not registered to the manager`,
 		},
 		{
-			scenario:      "count error",
+			scenario:      "file not found",
 			database:      defaultDatabase,
-			result:        []bson.D{{{Key: "ok", Value: 0}}},
-			expectedError: `could not count documents in collection "customer": command failed`,
+			filePath:      "resources/fixtures/dump/unknown.archive",
+			expectedError: `open resources/fixtures/dump/unknown.archive: no such file or directory`,
 		},
 		{
-			scenario:      "has documents",
+			scenario:      "not an archive",
 			database:      defaultDatabase,
-			result:        createCountResponse("db", "customer", 5),
-			expectedError: `collection "customer" has 5 document(s), expected none`,
+			filePath:      "resources/fixtures/customers.json",
+			expectedError: `could not restore archive "resources/fixtures/customers.json": not a mongodump archive`,
 		},
 		{
-			scenario: "no documents",
-			database: defaultDatabase,
-			result:   createCountResponse("db", "customer", 0),
+			scenario:         "store error",
+			database:         defaultDatabase,
+			filePath:         "resources/fixtures/dump/test.archive",
+			results:          []bson.D{mtest.CreateSuccessResponse(), {{Key: "ok", Value: 0}}},
+			expectedCleanUps: []string{"customer"},
+			expectedError:    `could not restore archive "resources/fixtures/dump/test.archive": could not insert documents into collection "customer": command failed`,
+		},
+		{
+			scenario:         "success",
+			database:         defaultDatabase,
+			filePath:         "resources/fixtures/dump/test.archive",
+			results:          []bson.D{mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse()},
+			expectedCleanUps: []string{"customer"},
+		},
+		{
+			scenario:         "success with gzip",
+			database:         defaultDatabase,
+			filePath:         "resources/fixtures/dump/test.archive.gz",
+			results:          []bson.D{mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse()},
+			expectedCleanUps: []string{"customer"},
 		},
 	}
 
@@ -312,31 +467,53 @@ func TestManager_NoDocumentsAreAvailableInCollectionOfDatabase(t *testing.T) {
 		mt.Run(tc.scenario, func(t *mtest.T) {
 			t.Parallel()
 
-			t.AddMockResponses(tc.result...)
+			t.AddMockResponses(tc.results...)
 
 			m := NewManager(WithDefaultDatabase(t.DB))
 
-			_, err := m.noDocumentsAreAvailableInCollectionOfDatabase(context.Background(), "customer", tc.database)
+			ctx, err := m.restoreDumpArchiveToDatabase(context.Background(), tc.filePath, tc.database)
 
-			if tc.expectedError == "" {
-				assert.NoError(t, err)
-			} else {
+			assert.Equal(t, tc.expectedCleanUps, cleanUpsFromContext(ctx, defaultDatabase))
+
+			if tc.expectedError != "" {
 				assert.EqualError(t, err, tc.expectedError)
+
+				return
 			}
+
+			assert.NoError(t, err)
+
+			events := t.GetAllStartedEvents()
+			if !assert.Len(t, events, 2) {
+				return
+			}
+
+			indexes, err := withoutFields(bsoncore.Document(events[0].Command), "$db", "lsid", "$readPreference", "writeConcern")
+			assert.NoError(t, err)
+
+			assertjson.Equal(t, []byte(`{
+				"createIndexes": "customer",
+				"indexes": [{"key": {"name": {"$numberInt": "1"}}, "name": "name_1"}]
+			}`), []byte(indexes.String()))
+
+			docs, err := events[1].Command.Lookup("documents").Array().Values()
+			assert.NoError(t, err)
+			assert.Len(t, docs, 2)
 		})
 	}
 }
 
-func TestManager_HaveNumberOfDocumentsAvailableInCollectionOfDatabase(t *testing.T) {
+func TestManager_SeedDatabaseFromDirectory(t *testing.T) {
 	t.Parallel()
 
-	const expectedNumberOfDocuments int64 = 5
-
 	testCases := []struct {
-		scenario      string
-		database      string
-		result        []bson.D
-		expectedError string
+		scenario         string
+		database         string
+		dirPath          string
+		results          []bson.D
+		expectedCommands []string
+		expectedCleanUps []string
+		expectedError    string
 	}{
 		{
 			scenario:      "missing database",
@@ -344,21 +521,42 @@ func TestManager_HaveNumberOfDocumentsAvailableInCollectionOfDatabase(t *testing
 			expectedError: `mongo database "other" is not registered to the manager`,
 		},
 		{
-			scenario:      "count error",
+			scenario:      "directory not found",
 			database:      defaultDatabase,
-			result:        []bson.D{{{Key: "ok", Value: 0}}},
-			expectedError: `could not count documents in collection "customer": command failed`,
+			dirPath:       "resources/fixtures/seed/unknown",
+			expectedError: `could not read fixture directory "resources/fixtures/seed/unknown": open resources/fixtures/seed/unknown: no such file or directory`,
 		},
 		{
-			scenario:      "mismatched",
+			scenario:      "truncate error",
 			database:      defaultDatabase,
-			result:        createCountResponse("db", "customer", 4),
-			expectedError: `collection "customer" has 4 document(s), expected 5`,
+			dirPath:       "resources/fixtures/seed/basic",
+			results:       []bson.D{{{Key: "ok", Value: 0}}},
+			expectedError: `could not truncate collection "customer": command failed`,
 		},
 		{
-			scenario: "matched",
+			scenario: "store error",
 			database: defaultDatabase,
-			result:   createCountResponse("db", "customer", expectedNumberOfDocuments),
+			dirPath:  "resources/fixtures/seed/basic",
+			results: []bson.D{
+				mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(), {{Key: "ok", Value: 0}},
+			},
+			expectedCleanUps: []string{"customer", "order", "invoice"},
+			expectedError:    `could not insert documents into collection "order": command failed`,
+		},
+		{
+			scenario: "success",
+			database: defaultDatabase,
+			dirPath:  "resources/fixtures/seed/basic",
+			results: []bson.D{
+				mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(),
+			},
+			expectedCommands: []string{
+				"delete customer", "delete order", "delete invoice",
+				"insert customer", "insert order", "insert invoice",
+			},
+			expectedCleanUps: []string{"customer", "order", "invoice"},
 		},
 	}
 
@@ -370,116 +568,3521 @@ func TestManager_HaveNumberOfDocumentsAvailableInCollectionOfDatabase(t *testing
 		mt.Run(tc.scenario, func(t *mtest.T) {
 			t.Parallel()
 
-			t.AddMockResponses(tc.result...)
+			t.AddMockResponses(tc.results...)
 
 			m := NewManager(WithDefaultDatabase(t.DB))
 
-			_, err := m.haveNumberOfDocumentsAvailableInCollectionOfDatabase(context.Background(), expectedNumberOfDocuments, "customer", tc.database)
+			ctx, err := m.seedDatabaseFromDirectory(context.Background(), tc.database, tc.dirPath)
 
-			if tc.expectedError == "" {
-				assert.NoError(t, err)
-			} else {
+			assert.Equal(t, tc.expectedCleanUps, cleanUpsFromContext(ctx, defaultDatabase))
+
+			if tc.expectedError != "" {
 				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			events := t.GetAllStartedEvents()
+			actualCommands := make([]string, len(events))
+
+			for i, e := range events {
+				actualCommands[i] = e.CommandName + " " + e.Command.Lookup(e.CommandName).StringValue()
 			}
+
+			assert.Equal(t, tc.expectedCommands, actualCommands)
 		})
 	}
 }
 
-func TestManager_HaveOnlyTheseDocumentsAvailableInCollectionOfDatabase(t *testing.T) {
+func TestManager_StoringDocumentsInCollectionOfDatabaseShouldFail(t *testing.T) {
 	t.Parallel()
 
-	fixtures := readFixtures("resources/fixtures/customers.json")
-	docs := mustParseDocs(fixtures)
-
-	const expectedIgnoredDiff = `[
-		{
-			"_id": "<ignored-diff>",
-			"name": "John Doe",
-			"age": 30,
-			"address": {
-				"street": "Street 1",
-				"city": "City 1",
-				"country": "Country 1"
-			}
-		},
-		{
-			"_id": "<ignored-diff>",
-			"name": "Jane Doe",
-			"age": 20,
-			"address": {
-				"street": "Street 2",
-				"city": "City 2",
-				"country": "Country 2"
-			}
-		}
-	]`
+	duplicateKey := mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"})
+	validation := mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 121, Message: "Document failed validation"})
+	data := &godog.DocString{Content: `[{"name": "John Doe"}]`}
 
 	testCases := []struct {
-		scenario      string
-		database      string
-		result        []bson.D
-		expectedDocs  string
-		expectedError string
+		scenario        string
+		database        string
+		kind            string
+		data            *godog.DocString
+		result          bson.D
+		expectedContext context.Context // nolint: containedctx
+		expectedError   string
 	}{
 		{
-			scenario:      "missing database",
-			database:      "other",
-			expectedError: `mongo database "other" is not registered to the manager`,
+			scenario:        "missing database",
+			database:        "other",
+			expectedContext: context.Background(),
+			expectedError:   `mongo database "other" is not registered to the manager`,
 		},
 		{
-			scenario:      "could not parse expected docs",
-			database:      defaultDatabase,
-			expectedDocs:  `[`,
-			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+			scenario:        "no data",
+			database:        defaultDatabase,
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse documents: data is nil`,
 		},
 		{
-			scenario:      "find error",
-			database:      defaultDatabase,
-			result:        []bson.D{{{Key: "ok", Value: 0}}},
-			expectedDocs:  `[{}]`,
-			expectedError: `could not find documents in collection "customer": command failed`,
+			scenario:        "stored",
+			database:        defaultDatabase,
+			data:            data,
+			result:          mtest.CreateSuccessResponse(),
+			expectedContext: context.Background(),
+			expectedError:   `documents are stored in collection "customer", expected an error`,
 		},
 		{
-			scenario:     "mismatched",
-			database:     defaultDatabase,
-			result:       createCursorResponse("db", "customer", bson.D{{Key: "name", Value: "Jane"}}),
-			expectedDocs: `[{"name": "John"}]`,
-			expectedError: `not equal:
- [
-   {
--    "name": "John"
-+    "name": "Jane"
-   }
- ]
-`,
+			scenario:        "stored with a kind",
+			database:        defaultDatabase,
+			kind:            writeErrorDuplicateKey,
+			data:            data,
+			result:          mtest.CreateSuccessResponse(),
+			expectedContext: context.Background(),
+			expectedError:   `documents are stored in collection "customer", expected duplicate key error`,
 		},
 		{
-			scenario:     "matched - equal",
-			database:     defaultDatabase,
-			result:       createDocsResponse("db", "customer", docs),
-			expectedDocs: string(fixtures),
+			scenario:        "different kind",
+			database:        defaultDatabase,
+			kind:            writeErrorValidation,
+			data:            data,
+			result:          duplicateKey,
+			expectedContext: context.Background(),
+			expectedError:   `expected validation error, got duplicate key error: could not insert documents into collection "customer": bulk write exception: write errors: [E11000 duplicate key error]`,
+		},
+		{
+			scenario:        "no documents",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `[]`},
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse documents: no documents`,
+		},
+		{
+			scenario:        "client error",
+			database:        defaultDatabase,
+			data:            data,
+			result:          bson.D{{Key: "ok", Value: 0}},
+			expectedContext: context.Background(),
+			expectedError:   `could not store documents in collection "customer", expected an error from the server: could not insert documents into collection "customer": command failed`,
+		},
+		{
+			scenario:        "any error",
+			database:        defaultDatabase,
+			data:            data,
+			result:          bson.D{{Key: "ok", Value: 0}, {Key: "code", Value: 2}, {Key: "errmsg", Value: "bad value"}},
+			expectedContext: contextWithWriteError(context.Background(), writeError{Kind: writeErrorOther, Code: 2, Message: "bad value"}),
+		},
+		{
+			scenario:        "duplicate key error",
+			database:        defaultDatabase,
+			kind:            writeErrorDuplicateKey,
+			data:            data,
+			result:          duplicateKey,
+			expectedContext: contextWithWriteError(context.Background(), writeError{Kind: writeErrorDuplicateKey, Code: 11000, Message: "E11000 duplicate key error"}),
+		},
+		{
+			scenario:        "validation error",
+			database:        defaultDatabase,
+			kind:            writeErrorValidation,
+			data:            data,
+			result:          validation,
+			expectedContext: contextWithWriteError(context.Background(), writeError{Kind: writeErrorValidation, Code: 121, Message: "Document failed validation"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			ctx, err := m.storingDocumentsInCollectionOfDatabaseShouldFail(context.Background(), "customer", tc.database, tc.kind, tc.data)
+
+			assert.Equal(t, tc.expectedContext, ctx)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_UpdateDocumentsInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		data            *godog.DocString
+		result          []bson.D
+		expectedContext context.Context // nolint: containedctx
+		expectedError   string
+	}{
+		{
+			scenario:        "missing database",
+			database:        "other",
+			expectedContext: context.Background(),
+			expectedError:   `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:        "no data",
+			database:        defaultDatabase,
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse update: data is nil`,
+		},
+		{
+			scenario:        "malformed data",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `malformed`},
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse update: error unmarshaling extjson: invalid JSON input`,
+		},
+		{
+			scenario:        "missing update",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `{"filter": {"name": "John Doe"}}`},
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse update: update is missing`,
+		},
+		{
+			scenario:        "update error",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `{"filter": {"name": "John Doe"}, "update": {"$set": {"age": 31}}}`},
+			result:          []bson.D{{{Key: "ok", Value: 0}}},
+			expectedContext: context.Background(),
+			expectedError:   `could not update documents in collection "customer": command failed`,
+		},
+		{
+			scenario:        "success",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `{"filter": {"name": "John Doe"}, "update": {"$inc": {"age": 1}}}`},
+			result:          []bson.D{mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 1})},
+			expectedContext: contextWithWriteResult(context.Background(), writeResult{Matched: 2, Modified: 1}),
+		},
+		{
+			scenario:        "missing filter",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `{"update": {"$unset": {"age": ""}}}`},
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse update: filter is missing`,
+		},
+		{
+			scenario:        "unsupported key",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `{"fliter": {"name": "John Doe"}, "update": {"$unset": {"age": ""}}}`},
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse update: unsupported update key "fliter"`,
+		},
+		{
+			scenario:        "success with empty filter",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `{"filter": {}, "update": {"$unset": {"age": ""}}}`},
+			result:          []bson.D{mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2})},
+			expectedContext: contextWithWriteResult(context.Background(), writeResult{Matched: 2, Modified: 2}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			ctx, err := m.updateDocumentsInCollectionOfDatabase(context.Background(), "customer", tc.database, tc.data)
+
+			assert.Equal(t, tc.expectedContext, ctx)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_UpsertDocumentsInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		data            *godog.DocString
+		result          []bson.D
+		expectedContext context.Context // nolint: containedctx
+		expectedError   string
+	}{
+		{
+			scenario:        "missing database",
+			database:        "other",
+			expectedContext: context.Background(),
+			expectedError:   `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:        "no data",
+			database:        defaultDatabase,
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse update: data is nil`,
+		},
+		{
+			scenario:        "upsert error",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `{"filter": {"name": "John Doe"}, "update": {"$set": {"age": 31}}}`},
+			result:          []bson.D{{{Key: "ok", Value: 0}}},
+			expectedContext: context.Background(),
+			expectedError:   `could not upsert documents in collection "customer": command failed`,
+		},
+		{
+			scenario: "inserted",
+			database: defaultDatabase,
+			data:     &godog.DocString{Content: `{"filter": {"name": "John Doe"}, "update": {"$set": {"age": 31}}}`},
+			result: []bson.D{mtest.CreateSuccessResponse(
+				bson.E{Key: "n", Value: 1},
+				bson.E{Key: "nModified", Value: 0},
+				bson.E{Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: "42"}}}},
+			)},
+			expectedContext: contextWithWriteResult(context.Background(), writeResult{Upserted: 1}),
+		},
+		{
+			scenario:        "updated",
+			database:        defaultDatabase,
+			data:            &godog.DocString{Content: `{"filter": {"name": "John Doe"}, "update": {"$set": {"age": 31}}}`},
+			result:          []bson.D{mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1})},
+			expectedContext: contextWithWriteResult(context.Background(), writeResult{Matched: 1, Modified: 1}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			ctx, err := m.upsertDocumentsInCollectionOfDatabase(context.Background(), "customer", tc.database, tc.data)
+
+			assert.Equal(t, tc.expectedContext, ctx)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_DeleteDocumentsFromCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		filter          *godog.DocString
+		result          []bson.D
+		expectedContext context.Context // nolint: containedctx
+		expectedError   string
+	}{
+		{
+			scenario:        "missing database",
+			database:        "other",
+			expectedContext: context.Background(),
+			expectedError:   `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:        "could not parse filter",
+			database:        defaultDatabase,
+			filter:          &godog.DocString{Content: `malformed`},
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse filter: error unmarshaling extjson: invalid JSON input`,
+		},
+		{
+			scenario:        "delete error",
+			database:        defaultDatabase,
+			filter:          &godog.DocString{Content: `{"age": {"$gt": 25}}`},
+			result:          []bson.D{{{Key: "ok", Value: 0}}},
+			expectedContext: context.Background(),
+			expectedError:   `could not delete documents from collection "customer": command failed`,
+		},
+		{
+			scenario:        "success",
+			database:        defaultDatabase,
+			filter:          &godog.DocString{Content: `{"age": {"$gt": 25}}`},
+			result:          []bson.D{mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1})},
+			expectedContext: contextWithWriteResult(context.Background(), writeResult{Deleted: 1}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			ctx, err := m.deleteDocumentsFromCollectionOfDatabase(context.Background(), "customer", tc.database, tc.filter)
+
+			assert.Equal(t, tc.expectedContext, ctx)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_SearchInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs(readFixtures("resources/fixtures/customers.json"))
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		filter          *godog.DocString
+		result          []bson.D
+		expectedContext context.Context // nolint: containedctx
+		expectedError   string
+	}{
+		{
+			scenario:        "missing database",
+			database:        "other",
+			expectedContext: context.Background(),
+			expectedError:   `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:        "could not parse filter",
+			database:        defaultDatabase,
+			filter:          &godog.DocString{Content: `malformed`},
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse filter: error unmarshaling extjson: invalid JSON input`,
+		},
+		{
+			scenario:        "filter on option fields",
+			database:        defaultDatabase,
+			filter:          &godog.DocString{Content: `{"limit": 5, "filter": "active"}`},
+			result:          createDocsResponse("db", "customer", docs),
+			expectedContext: contextWithSearchQuery(contextWithDocs(context.Background(), docs), searchQuery{Database: defaultDatabase, Collection: "customer", Find: &findQuery{Filter: bson.D{{Key: "limit", Value: int32(5)}, {Key: "filter", Value: "active"}}}}),
+		},
+		{
+			scenario:        "find error",
+			database:        defaultDatabase,
+			filter:          &godog.DocString{Content: `{}`},
+			result:          []bson.D{{{Key: "ok", Value: 0}}},
+			expectedContext: context.Background(),
+			expectedError:   `could not find documents in collection "customer": command failed`,
+		},
+		{
+			scenario:        "success without filter",
+			database:        defaultDatabase,
+			result:          createDocsResponse("db", "customer", docs),
+			expectedContext: contextWithSearchQuery(contextWithDocs(context.Background(), docs), searchQuery{Database: defaultDatabase, Collection: "customer", Find: &findQuery{Filter: bson.D{}}}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			ctx, err := m.searchInCollectionOfDatabase(context.Background(), "customer", tc.database, tc.filter)
+
+			assert.Equal(t, tc.expectedContext, ctx)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_SearchInCollectionOfDatabaseAs(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs(readFixtures("resources/fixtures/customers.json"))
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("named", func(t *mtest.T) {
+		t.Parallel()
+
+		t.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.customer", mtest.FirstBatch, docsToBSOND(docs)...),
+			mtest.CreateCursorResponse(0, "db.customer", mtest.FirstBatch, docsToBSOND(docs[:1])...),
+		)
+
+		m := NewManager(WithDefaultDatabase(t.DB))
+
+		ctx, err := m.searchInCollectionOfDatabaseAs(context.Background(), "customer", defaultDatabase, "before", nil)
+		assert.NoError(t, err)
+
+		ctx, err = m.searchInCollectionOfDatabase(ctx, "customer", defaultDatabase, &godog.DocString{Content: `{"filter": {"name": "John Doe"}}`})
+		assert.NoError(t, err)
+
+		before, ok := namedResultFromContext(ctx, "before")
+		assert.True(t, ok)
+		assert.Equal(t, docs, before)
+
+		// The unnamed steps use the latest result.
+		assert.Equal(t, docs[:1], docsFromContext(ctx))
+	})
+
+	mt.Run("error", func(t *mtest.T) {
+		t.Parallel()
+
+		m := NewManager(WithDefaultDatabase(t.DB))
+
+		_, err := m.searchInCollectionOfDatabaseAs(context.Background(), "customer", "other", "before", nil)
+		assert.EqualError(t, err, `mongo database "other" is not registered to the manager`)
+	})
+}
+
+func TestManager_SearchInCollectionOfDatabase_FindOptions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		query           string
+		expectedCommand string
+	}{
+		{
+			scenario:        "plain filter",
+			query:           `{"age": {"$gt": 25}}`,
+			expectedCommand: `{"find": "customer", "filter": {"age": {"$gt": {"$numberInt":"25"}}}, "limit": {"$numberLong":"0"}, "sort": {"_id": {"$numberInt":"1"}}}`,
+		},
+		{
+			scenario:        "plain filter with a filter field",
+			query:           `{"filter": "active", "name": "John"}`,
+			expectedCommand: `{"find": "customer", "filter": {"filter": "active","name": "John"}, "limit": {"$numberLong":"0"}, "sort": {"_id": {"$numberInt":"1"}}}`,
+		},
+		{
+			scenario: "query with options",
+			query: `{
+				"filter": {"age": {"$gt": 25}},
+				"sort": {"name": -1},
+				"projection": {"name": 1},
+				"limit": 10,
+				"skip": 5,
+				"collation": {"locale": "en", "strength": 2, "caseLevel": true},
+				"hint": {"age": 1}
+			}`,
+			expectedCommand: `{"find": "customer", "filter": {"age": {"$gt": {"$numberInt":"25"}}}, "sort": {"name": {"$numberInt":"-1"}}, "projection": {"name": {"$numberInt":"1"}}, "limit": {"$numberLong":"10"}, "skip": {"$numberLong":"5"}, "collation": {"locale": "en","caseLevel": true,"strength": {"$numberInt":"2"}}, "hint": {"age": {"$numberInt":"1"}}}`,
+		},
+		{
+			scenario:        "query without filter",
+			query:           `{"filter": null, "limit": 1}`,
+			expectedCommand: `{"find": "customer", "filter": {}, "limit": {"$numberLong":"1"}, "sort": {"_id": {"$numberInt":"1"}}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(createCursorResponse("db", "customer")...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.searchInCollectionOfDatabase(context.Background(), "customer", defaultDatabase, &godog.DocString{Content: tc.query})
+			assert.NoError(t, err)
+
+			cmd := t.GetStartedEvent().Command
+			actual := bsoncore.Document(cmd)
+
+			actual, err = withoutFields(actual, "$db", "lsid", "$readPreference")
+			assert.NoError(t, err)
+
+			assertjson.Equal(t, []byte(tc.expectedCommand), []byte(actual.String()))
+		})
+	}
+}
+
+func TestManager_RunAggregationOnCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[{"_id": "City 1", "count": 2}]`))
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$address.city"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: int32(1)}}}}}},
+	}
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		pipeline        *godog.DocString
+		result          []bson.D
+		expectedContext context.Context // nolint: containedctx
+		expectedError   string
+	}{
+		{
+			scenario:        "missing database",
+			database:        "other",
+			expectedContext: context.Background(),
+			expectedError:   `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:        "no pipeline",
+			database:        defaultDatabase,
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse pipeline: data is nil`,
+		},
+		{
+			scenario:        "could not parse pipeline",
+			database:        defaultDatabase,
+			pipeline:        &godog.DocString{Content: `malformed`},
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse pipeline: error unmarshaling extjson: invalid JSON input`,
+		},
+		{
+			scenario:        "aggregate error",
+			database:        defaultDatabase,
+			pipeline:        &godog.DocString{Content: `[{"$group": {"_id": "$address.city", "count": {"$sum": 1}}}]`},
+			result:          []bson.D{{{Key: "ok", Value: 0}}},
+			expectedContext: context.Background(),
+			expectedError:   `could not run aggregation on collection "customer": command failed`,
+		},
+		{
+			scenario:        "success",
+			database:        defaultDatabase,
+			pipeline:        &godog.DocString{Content: `[{"$group": {"_id": "$address.city", "count": {"$sum": 1}}}]`},
+			result:          createDocsResponse("db", "customer", docs),
+			expectedContext: contextWithSearchQuery(contextWithDocs(context.Background(), docs), searchQuery{Database: defaultDatabase, Collection: "customer", Pipeline: pipeline}),
+		},
+		{
+			scenario:        "no documents",
+			database:        defaultDatabase,
+			pipeline:        &godog.DocString{Content: `[]`},
+			result:          createCursorResponse("db", "customer"),
+			expectedContext: contextWithSearchQuery(contextWithDocs(context.Background(), []bsoncore.Document{}), searchQuery{Database: defaultDatabase, Collection: "customer", Pipeline: mongo.Pipeline{}}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			ctx, err := m.runAggregationOnCollectionOfDatabase(context.Background(), "customer", tc.database, tc.pipeline)
+
+			assert.Equal(t, tc.expectedContext, ctx)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_ApplyValidatorToCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		data            *godog.DocString
+		result          int
+		expectedCommand string
+		expectedError   string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "no data",
+			database:      defaultDatabase,
+			expectedError: `failed to parse validator: data is nil`,
+		},
+		{
+			scenario:      "malformed data",
+			database:      defaultDatabase,
+			data:          &godog.DocString{Content: `{`},
+			expectedError: `failed to parse validator: error unmarshaling extjson: invalid JSON input`,
+		},
+		{
+			scenario:      "apply error",
+			database:      defaultDatabase,
+			data:          &godog.DocString{Content: `{"$jsonSchema": {"required": ["name"]}}`},
+			expectedError: `could not apply validator to collection "customer": command failed`,
+		},
+		{
+			scenario: "plain validator",
+			database: defaultDatabase,
+			data:     &godog.DocString{Content: `{"$jsonSchema": {"required": ["name"]}}`},
+			result:   1,
+			expectedCommand: `{
+				"collMod": "customer",
+				"validator": {"$jsonSchema": {"required": ["name"]}}
+			}`,
+		},
+		{
+			scenario: "validator with level and action",
+			database: defaultDatabase,
+			data: &godog.DocString{Content: `{
+				"validator": {"$jsonSchema": {"required": ["name"]}},
+				"validationLevel": "moderate",
+				"validationAction": "warn"
+			}`},
+			result: 1,
+			expectedCommand: `{
+				"collMod": "customer",
+				"validator": {"$jsonSchema": {"required": ["name"]}},
+				"validationLevel": "moderate",
+				"validationAction": "warn"
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(bson.D{{Key: "ok", Value: tc.result}})
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.applyValidatorToCollectionOfDatabase(context.Background(), "customer", tc.database, tc.data)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+
+				actual, err := withoutFields(bsoncore.Document(t.GetStartedEvent().Command), "$db", "lsid", "$readPreference")
+				assert.NoError(t, err)
+
+				assertjson.Equal(t, []byte(tc.expectedCommand), []byte(actual.String()))
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_CreateIndexesInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		data            *godog.DocString
+		result          int
+		expectedCommand string
+		expectedError   string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "no data",
+			database:      defaultDatabase,
+			expectedError: `failed to parse indexes: data is nil`,
+		},
+		{
+			scenario:      "malformed data",
+			database:      defaultDatabase,
+			data:          &godog.DocString{Content: `[`},
+			expectedError: `failed to parse indexes: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario:      "missing key",
+			database:      defaultDatabase,
+			data:          &godog.DocString{Content: `[{"name": "email_1"}]`},
+			expectedError: `failed to parse indexes: index #1 has no key`,
+		},
+		{
+			scenario:      "create error",
+			database:      defaultDatabase,
+			data:          &godog.DocString{Content: `[{"key": {"email": 1}}]`},
+			expectedError: `could not create indexes in collection "customer": command failed`,
+		},
+		{
+			scenario: "success",
+			database: defaultDatabase,
+			data:     &godog.DocString{Content: `[{"key": {"email": 1}, "unique": true}, {"key": {"name": 1, "age": -1}, "name": "name_age"}]`},
+			result:   1,
+			expectedCommand: `{
+				"createIndexes": "customer",
+				"indexes": [
+					{"key": {"email": {"$numberInt": "1"}}, "unique": true, "name": "email_1"},
+					{"key": {"name": {"$numberInt": "1"}, "age": {"$numberInt": "-1"}}, "name": "name_age"}
+				]
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(bson.D{{Key: "ok", Value: tc.result}})
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.createIndexesInCollectionOfDatabase(context.Background(), "customer", tc.database, tc.data)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+
+				actual, err := withoutFields(bsoncore.Document(t.GetStartedEvent().Command), "$db", "lsid", "$readPreference")
+				assert.NoError(t, err)
+
+				assertjson.Equal(t, []byte(tc.expectedCommand), []byte(actual.String()))
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_DropIndexFromCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        int
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "drop error",
+			database:      defaultDatabase,
+			expectedError: `could not drop index "email_1" from collection "customer": command failed`,
+		},
+		{
+			scenario: "success",
+			database: defaultDatabase,
+			result:   1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(bson.D{{Key: "ok", Value: tc.result}})
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.dropIndexFromCollectionOfDatabase(context.Background(), "email_1", "customer", tc.database)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_DropAllIndexesFromCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        int
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "drop error",
+			database:      defaultDatabase,
+			expectedError: `could not drop indexes from collection "customer": command failed`,
+		},
+		{
+			scenario: "success",
+			database: defaultDatabase,
+			result:   1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(bson.D{{Key: "ok", Value: tc.result}})
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.dropAllIndexesFromCollectionOfDatabase(context.Background(), "customer", tc.database)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_CreateCollectionInDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		data            *godog.DocString
+		result          int
+		expectedCommand string
+		expectedError   string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "malformed data",
+			database:      defaultDatabase,
+			data:          &godog.DocString{Content: `{`},
+			expectedError: `failed to parse collection options: error unmarshaling extjson: invalid JSON input`,
+		},
+		{
+			scenario:      "unsupported option",
+			database:      defaultDatabase,
+			data:          &godog.DocString{Content: `{"autoIndexId": false}`},
+			expectedError: `failed to parse collection options: unsupported collection option "autoIndexId"`,
+		},
+		{
+			scenario:      "create error",
+			database:      defaultDatabase,
+			expectedError: `could not create collection "customer": command failed`,
+		},
+		{
+			scenario:        "no options",
+			database:        defaultDatabase,
+			result:          1,
+			expectedCommand: `{"create": "customer", "writeConcern": {"w": "majority"}}`,
+		},
+		{
+			scenario: "with options",
+			database: defaultDatabase,
+			data: &godog.DocString{Content: `{
+				"capped": true,
+				"size": 4096,
+				"max": 10,
+				"collation": {"locale": "en", "strength": 2},
+				"validator": {"name": {"$exists": true}},
+				"validationLevel": "strict",
+				"validationAction": "error"
+			}`},
+			result: 1,
+			expectedCommand: `{
+				"create": "customer",
+				"capped": true,
+				"collation": {"locale": "en", "strength": {"$numberInt": "2"}},
+				"max": {"$numberLong": "10"},
+				"size": {"$numberLong": "4096"},
+				"validationAction": "error",
+				"validationLevel": "strict",
+				"validator": {"name": {"$exists": true}},
+				"writeConcern": {"w": "majority"}
+			}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(bson.D{{Key: "ok", Value: tc.result}})
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.createCollectionInDatabase(context.Background(), "customer", tc.database, tc.data)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+
+				actual, err := withoutFields(bsoncore.Document(t.GetStartedEvent().Command), "$db", "lsid", "$readPreference")
+				assert.NoError(t, err)
+
+				assertjson.Equal(t, []byte(tc.expectedCommand), []byte(actual.String()))
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_DropCollectionFromDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        int
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "drop error",
+			database:      defaultDatabase,
+			expectedError: `could not drop collection "customer": command failed`,
+		},
+		{
+			scenario: "success",
+			database: defaultDatabase,
+			result:   1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(bson.D{{Key: "ok", Value: tc.result}})
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.dropCollectionFromDatabase(context.Background(), "customer", tc.database)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_RenameCollectionInDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        int
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "rename error",
+			database:      defaultDatabase,
+			expectedError: `could not rename collection "customer" to "client": command failed`,
+		},
+		{
+			scenario: "success",
+			database: defaultDatabase,
+			result:   1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(bson.D{{Key: "ok", Value: tc.result}})
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.renameCollectionInDatabase(context.Background(), "customer", tc.database, "client")
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+
+				actual, err := withoutFields(bsoncore.Document(t.GetStartedEvent().Command), "lsid", "$readPreference")
+				assert.NoError(t, err)
+
+				expected := fmt.Sprintf(`{"renameCollection": "%[1]s.customer", "to": "%[1]s.client", "$db": "admin"}`, t.DB.Name())
+
+				assertjson.Equal(t, []byte(expected), []byte(actual.String()))
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_NoDocumentsAreAvailableInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        []bson.D
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "count error",
+			database:      defaultDatabase,
+			result:        []bson.D{{{Key: "ok", Value: 0}}},
+			expectedError: `could not count documents in collection "customer": command failed`,
+		},
+		{
+			scenario:      "has documents",
+			database:      defaultDatabase,
+			result:        createCountResponse("db", "customer", 5),
+			expectedError: `collection "customer" has 5 document(s), expected none`,
+		},
+		{
+			scenario: "no documents",
+			database: defaultDatabase,
+			result:   createCountResponse("db", "customer", 0),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.noDocumentsAreAvailableInCollectionOfDatabase(context.Background(), "customer", tc.database)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveNumberOfDocumentsAvailableInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	const expectedNumberOfDocuments int64 = 5
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        []bson.D
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "count error",
+			database:      defaultDatabase,
+			result:        []bson.D{{{Key: "ok", Value: 0}}},
+			expectedError: `could not count documents in collection "customer": command failed`,
+		},
+		{
+			scenario:      "mismatched",
+			database:      defaultDatabase,
+			result:        createCountResponse("db", "customer", 4),
+			expectedError: `collection "customer" has 4 document(s), expected 5`,
+		},
+		{
+			scenario: "matched",
+			database: defaultDatabase,
+			result:   createCountResponse("db", "customer", expectedNumberOfDocuments),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveNumberOfDocumentsAvailableInCollectionOfDatabase(context.Background(), expectedNumberOfDocuments, "customer", tc.database)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveNumberOfDocumentsMatchingInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	const expectedNumberOfDocuments int64 = 5
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		comparison    string
+		filter        *godog.DocString
+		result        []bson.D
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "could not parse filter",
+			database:      defaultDatabase,
+			filter:        &godog.DocString{Content: `malformed`},
+			expectedError: `failed to parse filter: error unmarshaling extjson: invalid JSON input`,
+		},
+		{
+			scenario:      "count error",
+			database:      defaultDatabase,
+			filter:        &godog.DocString{Content: `{"status": "active"}`},
+			result:        []bson.D{{{Key: "ok", Value: 0}}},
+			expectedError: `could not count documents in collection "customer": command failed`,
+		},
+		{
+			scenario:      "mismatched",
+			database:      defaultDatabase,
+			filter:        &godog.DocString{Content: `{"status": "active"}`},
+			result:        createCountResponse("db", "customer", 4),
+			expectedError: `collection "customer" has 4 document(s) matching the query, expected 5`,
+		},
+		{
+			scenario: "matched",
+			database: defaultDatabase,
+			filter:   &godog.DocString{Content: `{"status": "active"}`},
+			result:   createCountResponse("db", "customer", expectedNumberOfDocuments),
+		},
+		{
+			scenario:      "at least - mismatched",
+			database:      defaultDatabase,
+			comparison:    "at least",
+			filter:        &godog.DocString{Content: `{"status": "active"}`},
+			result:        createCountResponse("db", "customer", 4),
+			expectedError: `collection "customer" has 4 document(s) matching the query, expected at least 5`,
+		},
+		{
+			scenario:   "at least - matched",
+			database:   defaultDatabase,
+			comparison: "at least",
+			filter:     &godog.DocString{Content: `{"status": "active"}`},
+			result:     createCountResponse("db", "customer", 6),
+		},
+		{
+			scenario:      "at most - mismatched",
+			database:      defaultDatabase,
+			comparison:    "at most",
+			filter:        &godog.DocString{Content: `{"status": "active"}`},
+			result:        createCountResponse("db", "customer", 6),
+			expectedError: `collection "customer" has 6 document(s) matching the query, expected at most 5`,
+		},
+		{
+			scenario:   "at most - matched",
+			database:   defaultDatabase,
+			comparison: "at most",
+			filter:     &godog.DocString{Content: `{"status": "active"}`},
+			result:     createCountResponse("db", "customer", 5),
+		},
+		{
+			scenario:      "more than - mismatched",
+			database:      defaultDatabase,
+			comparison:    "more than",
+			filter:        &godog.DocString{Content: `{"status": "active"}`},
+			result:        createCountResponse("db", "customer", 5),
+			expectedError: `collection "customer" has 5 document(s) matching the query, expected more than 5`,
+		},
+		{
+			scenario:   "less than - matched",
+			database:   defaultDatabase,
+			comparison: "less than",
+			filter:     &godog.DocString{Content: `{"status": "active"}`},
+			result:     createCountResponse("db", "customer", 4),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveNumberOfDocumentsMatchingInCollectionOfDatabase(context.Background(), tc.comparison, expectedNumberOfDocuments, "customer", tc.database, tc.filter)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveOnlyTheseDocumentsAvailableInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	fixtures := readFixtures("resources/fixtures/customers.json")
+	docs := mustParseDocs(fixtures)
+
+	const expectedIgnoredDiff = `[
+		{
+			"_id": "<ignored-diff>",
+			"name": "John Doe",
+			"age": 30,
+			"address": {
+				"street": "Street 1",
+				"city": "City 1",
+				"country": "Country 1"
+			}
+		},
+		{
+			"_id": "<ignored-diff>",
+			"name": "Jane Doe",
+			"age": 20,
+			"address": {
+				"street": "Street 2",
+				"city": "City 2",
+				"country": "Country 2"
+			}
+		}
+	]`
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        []bson.D
+		expectedDocs  string
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "could not parse expected docs",
+			database:      defaultDatabase,
+			expectedDocs:  `[`,
+			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario:      "find error",
+			database:      defaultDatabase,
+			result:        []bson.D{{{Key: "ok", Value: 0}}},
+			expectedDocs:  `[{}]`,
+			expectedError: `could not find documents in collection "customer": command failed`,
+		},
+		{
+			scenario:     "mismatched",
+			database:     defaultDatabase,
+			result:       createCursorResponse("db", "customer", bson.D{{Key: "name", Value: "Jane"}}),
+			expectedDocs: `[{"name": "John"}]`,
+			expectedError: `not equal:
+ [
+   {
+-    "name": "John"
++    "name": "Jane"
+   }
+ ]
+`,
+		},
+		{
+			scenario:     "matched - equal",
+			database:     defaultDatabase,
+			result:       createDocsResponse("db", "customer", docs),
+			expectedDocs: string(fixtures),
+		},
+		{
+			scenario:     "matched - ignored diff",
+			database:     defaultDatabase,
+			result:       createDocsResponse("db", "customer", docs),
+			expectedDocs: expectedIgnoredDiff,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveOnlyTheseDocumentsAvailableInCollectionOfDatabase(context.Background(), "customer", tc.database, &godog.DocString{Content: tc.expectedDocs})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveOnlyTheseDocumentsInAnyOrderAvailableInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[{"name": "John"}, {"name": "Jane"}, {"name": "Jane"}]`))
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        []bson.D
+		expectedDocs  string
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "find error",
+			database:      defaultDatabase,
+			result:        []bson.D{{{Key: "ok", Value: 0}}},
+			expectedDocs:  `[{}]`,
+			expectedError: `could not find documents in collection "customer": command failed`,
+		},
+		{
+			scenario:     "mismatched",
+			database:     defaultDatabase,
+			result:       createDocsResponse("db", "customer", docs),
+			expectedDocs: `[{"name": "Jane"}, {"name": "Jack"}, {"name": "John"}]`,
+			expectedError: `collection "customer" does not have the expected documents: 1 expected document(s) have no match and 1 actual document(s) are unexpected
+
+expected document #2:
+{
+    "name": "Jack"
+}
+
+unexpected document #3:
+{
+    "name": "Jane"
+}
+`,
+		},
+		{
+			scenario:     "matched",
+			database:     defaultDatabase,
+			result:       createDocsResponse("db", "customer", docs),
+			expectedDocs: `[{"name": "Jane"}, {"name": "<ignore-diff>"}, {"name": "John"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveOnlyTheseDocumentsInAnyOrderAvailableInCollectionOfDatabase(context.Background(), "customer", tc.database, &godog.DocString{Content: tc.expectedDocs})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveOnlyTheseRowsInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[{"name": "John", "age": 30}, {"name": "Jane", "age": 25}]`))
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		order         string
+		table         *godog.Table
+		result        []bson.D
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "no table",
+			database:      defaultDatabase,
+			expectedError: `failed to parse expected rows: table is empty`,
+		},
+		{
+			scenario: "different order",
+			database: defaultDatabase,
+			table:    newTable([]string{"name", "age"}, []string{"Jane", "25"}, []string{"John", "30"}),
+			result:   createDocsResponse("db", "customer", docs),
+			expectedError: `not equal:
+ [
+   {
+     "age": {
+       "$numberInt": "30"
+     },
+     "name": "John"
+   },
+ ]
+`,
+		},
+		{
+			scenario: "in any order",
+			database: defaultDatabase,
+			order:    " in any order",
+			table:    newTable([]string{"name", "age"}, []string{"Jane", "25"}, []string{"John", "30"}),
+			result:   createDocsResponse("db", "customer", docs),
+		},
+		{
+			scenario: "same order",
+			database: defaultDatabase,
+			table:    newTable([]string{"name", "age"}, []string{"John", "30"}, []string{"Jane", "25"}),
+			result:   createDocsResponse("db", "customer", docs),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveOnlyTheseRowsInCollectionOfDatabase(context.Background(), "customer", tc.database, tc.order, tc.table)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveOnlyTheseDocumentsAvailableInCollectionOfDatabase_CompareDocumentsInAnyOrder(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("matched", func(t *mtest.T) {
+		t.Parallel()
+
+		t.AddMockResponses(createDocsResponse("db", "customer", mustParseDocs([]byte(`[{"name": "John"}, {"name": "Jane"}]`)))...)
+
+		m := NewManager(WithDefaultDatabase(t.DB, CompareDocumentsInAnyOrder()))
+
+		_, err := m.haveOnlyTheseDocumentsAvailableInCollectionOfDatabase(context.Background(), "customer", defaultDatabase, &godog.DocString{Content: `[{"name": "Jane"}, {"name": "John"}]`})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestManager_ContainTheseDocumentsInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs(readFixtures("resources/fixtures/customers.json"))
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		result        []bson.D
+		expectedDocs  string
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "could not parse expected docs",
+			database:      defaultDatabase,
+			expectedDocs:  `[`,
+			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario:      "find error",
+			database:      defaultDatabase,
+			result:        []bson.D{{{Key: "ok", Value: 0}}},
+			expectedDocs:  `[{}]`,
+			expectedError: `could not find documents in collection "customer": command failed`,
+		},
+		{
+			scenario:     "no match",
+			database:     defaultDatabase,
+			result:       createDocsResponse("db", "customer", docs),
+			expectedDocs: `[{"name": "John Doe"}, {"name": "Jane Doe", "age": 21}]`,
+			expectedError: `collection "customer" does not contain the expected documents: could not find a match for 1 expected document(s)
+
+expected document #2:
+{
+    "name": "Jane Doe",
+    "age": {
+        "$numberInt": "21"
+    }
+}
+closest candidate:
+{
+    "_id": {
+        "$oid": "6250053966df8910f804c3a8"
+    },
+    "name": "Jane Doe",
+    "age": {
+        "$numberInt": "20"
+    },
+    "address": {
+        "street": "Street 2",
+        "city": "City 2",
+        "country": "Country 2"
+    }
+}
+`,
+		},
+		{
+			scenario:     "no candidate",
+			database:     defaultDatabase,
+			result:       createCursorResponse("db", "customer"),
+			expectedDocs: `[{"name": "John Doe"}]`,
+			expectedError: `collection "customer" does not contain the expected documents: could not find a match for 1 expected document(s)
+
+expected document #1:
+{
+    "name": "John Doe"
+}
+closest candidate:
+none
+`,
+		},
+		{
+			scenario:     "matched",
+			database:     defaultDatabase,
+			result:       createDocsResponse("db", "customer", docs),
+			expectedDocs: `[{"name": "Jane Doe", "address": {"city": "City 2"}}, {"_id": "<ignore-diff>", "age": 30}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.containTheseDocumentsInCollectionOfDatabase(context.Background(), "customer", tc.database, &godog.DocString{Content: tc.expectedDocs})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs(readFixtures("resources/fixtures/customers.json"))
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		filePath      string
+		result        []bson.D
+		expectedError string
+	}{
+		{
+			scenario:      "file not found",
+			database:      "other",
+			filePath:      "resources/fixtures/unknown.json",
+			expectedError: `open resources/fixtures/unknown.json: no such file or directory`,
+		},
+		{
+			scenario:      "missing database",
+			database:      "other",
+			filePath:      "resources/fixtures/empty.json",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "could not parse expected docs",
+			database:      defaultDatabase,
+			filePath:      "resources/fixtures/malformed.json",
+			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario:      "find error",
+			database:      defaultDatabase,
+			filePath:      "resources/fixtures/empty.json",
+			result:        []bson.D{{{Key: "ok", Value: 0}}},
+			expectedError: `could not find documents in collection "customer": command failed`,
+		},
+		{
+			scenario: "mismatched",
+			database: defaultDatabase,
+			filePath: "resources/fixtures/empty.json",
+			result:   createCursorResponse("db", "customer", bson.D{{Key: "name", Value: "Jane"}}),
+			expectedError: `not equal:
+ [
++  {
++    "name": "Jane"
++  }
+ ]
+`,
+		},
+		{
+			scenario: "matched - no documents",
+			database: defaultDatabase,
+			filePath: "resources/fixtures/empty.json",
+			result:   createCursorResponse("db", "customer"),
+		},
+		{
+			scenario:      "could not parse expected yaml",
+			database:      defaultDatabase,
+			filePath:      "resources/fixtures/malformed.yaml",
+			expectedError: `failed to parse expected documents: error unmarshaling yaml: yaml: line 1: did not find expected ',' or ']'`,
+		},
+		{
+			scenario: "matched - has documents",
+			database: defaultDatabase,
+			filePath: "resources/fixtures/customers.json",
+			result:   createDocsResponse("db", "customer", docs),
+		},
+		{
+			scenario: "matched - has documents in yaml",
+			database: defaultDatabase,
+			filePath: "resources/fixtures/customers.yaml",
+			result:   createDocsResponse("db", "customer", docs),
+		},
+		{
+			scenario: "matched - has documents in ndjson",
+			database: defaultDatabase,
+			filePath: "resources/fixtures/customers.ndjson",
+			result:   createDocsResponse("db", "customer", docs),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(context.Background(), tc.filePath, "customer", tc.database)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase_WithExpectedFilesUpdate(t *testing.T) {
+	t.Parallel()
+
+	oid, err := primitive.ObjectIDFromHex("6250053966df8910f804c3a7")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx := ContextWithVariable(context.Background(), "name", "Jane")
+	ctx = ContextWithVariable(ctx, "customer", oid)
+
+	testCases := []struct {
+		scenario      string
+		fileName      string
+		content       string
+		expected      string
+		expectedError string
+	}{
+		{
+			scenario: "mismatched",
+			fileName: "customer.json",
+			content:  `[{"_id": "<ignore-diff>", "name": "John", "city": "Paris"}]`,
+			expected: `[{"_id": "<ignore-diff>", "name": "Jane", "city": "Rome"}]`,
+		},
+		{
+			scenario: "not exist",
+			fileName: "customer.json",
+			expected: `[{"_id": {"$oid": "6250053966df8910f804c3a7"}, "name": "Jane", "city": "Rome"}]`,
+		},
+		{
+			scenario: "yaml with variables",
+			fileName: "customer.yaml",
+			content:  "- _id: <ignore-diff>\n  name: \"{{ .name }}\"\n  city: Paris\n",
+			expected: "- _id: <ignore-diff>\n  name: '{{ .name }}'\n  city: Rome\n",
+		},
+		{
+			scenario: "generated values",
+			fileName: "customer.json",
+			content:  `[{"_id": {"$newOid": "customer"}, "name": "John", "city": "Paris"}]`,
+			expected: `[{"_id": {"$newOid": "customer"}, "name": "Jane", "city": "Rome"}]`,
+		},
+		{
+			scenario:      "placeholders not kept",
+			fileName:      "customer.json",
+			content:       `[{"_id": "<ignore-diff>", "key": {"$uuid": "key"}, "name": "Jane"}]`,
+			expected:      `[{"_id": "<ignore-diff>", "key": {"$uuid": "key"}, "name": "Jane"}]`,
+			expectedError: `could not update "%s" without losing its variables or generated values, update it manually: `,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			filePath := filepath.Join(t.TempDir(), tc.fileName)
+
+			if tc.content != "" {
+				err := os.WriteFile(filePath, []byte(tc.content), 0o600)
+				if !assert.NoError(t, err) {
+					return
+				}
+			}
+
+			t.AddMockResponses(createDocsResponse("db", "customer", mustParseDocs([]byte(`[{"_id": {"$oid": "6250053966df8910f804c3a7"}, "name": "Jane", "city": "Rome"}]`)))...)
+
+			m := NewManager(WithDefaultDatabase(t.DB), WithExpectedFilesUpdate())
+
+			_, err := m.haveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(ctx, filePath, "customer", defaultDatabase)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), fmt.Sprintf(tc.expectedError, filePath))
+			}
+
+			if filepath.Ext(filePath) == ".yaml" {
+				assert.Equal(t, tc.expected, string(readFixtures(filePath)))
+			} else {
+				assert.JSONEq(t, tc.expected, string(readFixtures(filePath)))
+			}
+		})
+	}
+}
+
+func TestManager_HaveDatabaseMatchingDirectory(t *testing.T) {
+	t.Parallel()
+
+	seedDocs := func(t *mtest.T, collectionName string) []bsoncore.Document {
+		t.Helper()
+
+		ext := map[string]string{"customer": ".json", "order": ".yaml", "invoice": ".ndjson"}[collectionName]
+
+		r, err := openDocsFile("resources/fixtures/seed/basic/"+collectionName+ext, nil)
+		assert.NoError(t, err)
+
+		docs, err := readAllDocs(r)
+		assert.NoError(t, err)
+
+		return docs
+	}
+
+	// The cursors are exhausted in their first batch, so that every find consumes a single response.
+	findResponse := func(t *mtest.T, collectionName string, docs []bsoncore.Document) bson.D {
+		t.Helper()
+
+		return mtest.CreateCursorResponse(0, t.DB.Name()+"."+collectionName, mtest.FirstBatch, docsToBSOND(docs)...)
+	}
+
+	testCases := []struct {
+		scenario           string
+		database           string
+		dirPath            string
+		noOtherCollections string
+		mockResponses      func(t *mtest.T) []bson.D
+		expectedErrors     []string
+	}{
+		{
+			scenario:       "missing database",
+			database:       "other",
+			dirPath:        "resources/fixtures/seed/basic",
+			expectedErrors: []string{`mongo database "other" is not registered to the manager`},
+		},
+		{
+			scenario:       "directory not found",
+			database:       defaultDatabase,
+			dirPath:        "resources/fixtures/seed/unknown",
+			expectedErrors: []string{`could not read fixture directory "resources/fixtures/seed/unknown": open resources/fixtures/seed/unknown: no such file or directory`},
+		},
+		{
+			scenario: "match",
+			database: defaultDatabase,
+			dirPath:  "resources/fixtures/seed/basic",
+			mockResponses: func(t *mtest.T) []bson.D {
+				var responses []bson.D
+
+				for _, collectionName := range []string{"customer", "order", "invoice"} {
+					responses = append(responses, findResponse(t, collectionName, seedDocs(t, collectionName)))
+				}
+
+				return responses
+			},
+		},
+		{
+			scenario: "mismatch in many collections",
+			database: defaultDatabase,
+			dirPath:  "resources/fixtures/seed/basic",
+			mockResponses: func(t *mtest.T) []bson.D {
+				return []bson.D{
+					findResponse(t, "customer", nil),
+					findResponse(t, "order", seedDocs(t, "order")),
+					findResponse(t, "invoice", seedDocs(t, "invoice")[:1]),
+				}
+			},
+			expectedErrors: []string{
+				`database "default" does not match directory "resources/fixtures/seed/basic", 2 collection(s) differ:`,
+				"\ncollection \"customer\":\n",
+				"\ncollection \"invoice\":\n",
+			},
+		},
+		{
+			scenario:           "other collections",
+			database:           defaultDatabase,
+			dirPath:            "resources/fixtures/seed/basic",
+			noOtherCollections: " and have no other collections",
+			mockResponses: func(t *mtest.T) []bson.D {
+				var responses []bson.D
+
+				for _, collectionName := range []string{"customer", "order", "invoice"} {
+					responses = append(responses, findResponse(t, collectionName, seedDocs(t, collectionName)))
+				}
+
+				return append(responses,
+					mtest.CreateCursorResponse(0, t.DB.Name()+".$cmd.listCollections", mtest.FirstBatch,
+						bson.D{{Key: "name", Value: "invoice"}},
+						bson.D{{Key: "name", Value: "system.views"}},
+						bson.D{{Key: "name", Value: "empty"}},
+						bson.D{{Key: "name", Value: "customer"}},
+						bson.D{{Key: "name", Value: "audit"}},
+						bson.D{{Key: "name", Value: "order"}},
+					),
+					mtest.CreateCursorResponse(0, t.DB.Name()+".audit", mtest.FirstBatch, bson.D{{Key: "n", Value: int64(1)}}),
+					mtest.CreateCursorResponse(0, t.DB.Name()+".empty", mtest.FirstBatch),
+				)
+			},
+			expectedErrors: []string{
+				`database "default" does not match directory "resources/fixtures/seed/basic", 1 collection(s) differ:` + "\n" +
+					"\ncollection \"audit\" has documents but no file in the directory\n",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			if tc.mockResponses != nil {
+				t.AddMockResponses(tc.mockResponses(t)...)
+			}
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveDatabaseMatchingDirectory(context.Background(), tc.database, tc.dirPath, tc.noOtherCollections)
+
+			if len(tc.expectedErrors) == 0 {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			if !assert.Error(t, err) {
+				return
+			}
+
+			for _, expected := range tc.expectedErrors {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestManager_HaveDatabaseMatchingDirectory_WithExpectedFilesUpdate(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("updated", func(t *mtest.T) {
+		t.Parallel()
+
+		dirPath := t.TempDir()
+
+		err := os.WriteFile(filepath.Join(dirPath, "customer.json"), []byte(`[{"name": "John"}]`), 0o600)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		t.AddMockResponses(
+			mtest.CreateCursorResponse(0, t.DB.Name()+".customer", mtest.FirstBatch, bson.D{{Key: "name", Value: "Jane"}}),
+			mtest.CreateCursorResponse(0, t.DB.Name()+".$cmd.listCollections", mtest.FirstBatch,
+				bson.D{{Key: "name", Value: "audit"}},
+				bson.D{{Key: "name", Value: "customer"}},
+			),
+			mtest.CreateCursorResponse(0, t.DB.Name()+".audit", mtest.FirstBatch, bson.D{{Key: "n", Value: int64(1)}}),
+			mtest.CreateCursorResponse(0, t.DB.Name()+".audit", mtest.FirstBatch, bson.D{{Key: "action", Value: "login"}}),
+		)
+
+		m := NewManager(WithDefaultDatabase(t.DB), WithExpectedFilesUpdate())
+
+		_, err = m.haveDatabaseMatchingDirectory(context.Background(), defaultDatabase, dirPath, " and have no other collections")
+		assert.NoError(t, err)
+
+		assert.JSONEq(t, `[{"name": "Jane"}]`, string(readFixtures(filepath.Join(dirPath, "customer.json"))))
+		assert.JSONEq(t, `[{"action": "login"}]`, string(readFixtures(filepath.Join(dirPath, "audit.json"))))
+	})
+}
+
+func TestManager_HaveCollectionInDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		mockResponses func(dbName string) []bson.D
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			mockResponses: func(string) []bson.D { return nil },
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario: "list error",
+			database: defaultDatabase,
+			mockResponses: func(string) []bson.D {
+				return []bson.D{{{Key: "ok", Value: 0}}}
+			},
+			expectedError: `could not list collections: command failed`,
+		},
+		{
+			scenario: "not exist",
+			database: defaultDatabase,
+			mockResponses: func(dbName string) []bson.D {
+				return createCursorResponse(dbName, "$cmd.listCollections")
+			},
+			expectedError: `collection "customer" does not exist`,
+		},
+		{
+			scenario: "exist",
+			database: defaultDatabase,
+			mockResponses: func(dbName string) []bson.D {
+				return createCursorResponse(dbName, "$cmd.listCollections", bson.D{{Key: "name", Value: "customer"}})
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.mockResponses(t.DB.Name())...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveCollectionInDatabase(context.Background(), "customer", tc.database)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveNoCollectionInDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		mockResponses func(dbName string) []bson.D
+		expectedError string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			mockResponses: func(string) []bson.D { return nil },
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario: "list error",
+			database: defaultDatabase,
+			mockResponses: func(string) []bson.D {
+				return []bson.D{{{Key: "ok", Value: 0}}}
+			},
+			expectedError: `could not list collections: command failed`,
+		},
+		{
+			scenario: "exist",
+			database: defaultDatabase,
+			mockResponses: func(dbName string) []bson.D {
+				return createCursorResponse(dbName, "$cmd.listCollections", bson.D{{Key: "name", Value: "customer"}})
+			},
+			expectedError: `collection "customer" exists`,
+		},
+		{
+			scenario: "not exist",
+			database: defaultDatabase,
+			mockResponses: func(dbName string) []bson.D {
+				return createCursorResponse(dbName, "$cmd.listCollections")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.mockResponses(t.DB.Name())...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveNoCollectionInDatabase(context.Background(), "customer", tc.database)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveTheseIndexesInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	indexes := []bson.D{
+		{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}, {Key: "name", Value: "_id_"}, {Key: "ns", Value: "db.customer"}},
+		{{Key: "v", Value: 2}, {Key: "unique", Value: true}, {Key: "key", Value: bson.D{{Key: "email", Value: 1}}}, {Key: "name", Value: "email_1"}, {Key: "ns", Value: "db.customer"}},
+	}
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		result          []bson.D
+		expectedIndexes string
+		expectedError   string
+	}{
+		{
+			scenario:      "missing database",
+			database:      "other",
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:        "could not parse expected indexes",
+			database:        defaultDatabase,
+			expectedIndexes: `[`,
+			expectedError:   `failed to parse expected indexes: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario:        "list error",
+			database:        defaultDatabase,
+			result:          []bson.D{{{Key: "ok", Value: 0}}},
+			expectedIndexes: `[]`,
+			expectedError:   `could not list indexes of collection "customer": command failed`,
+		},
+		{
+			scenario:        "mismatched",
+			database:        defaultDatabase,
+			result:          createCursorResponse("db", "customer", indexes...),
+			expectedIndexes: `[{"key": {"_id": 1}, "name": "_id_"}, {"key": {"email": 1}, "name": "email_1"}]`,
+			expectedError: `not equal:
+ [
+   {
+     "key": {
+       "_id": {
+         "$numberInt": "1"
+       }
+     },
+     "name": "_id_"
+   },
+   {
+     "key": {
+       "email": {
+         "$numberInt": "1"
+       }
+     },
+     "name": "email_1"
++    "unique": true
+   }
+ ]
+`,
+		},
+		{
+			scenario:        "matched",
+			database:        defaultDatabase,
+			result:          createCursorResponse("db", "customer", indexes...),
+			expectedIndexes: `[{"key": {"_id": 1}, "name": "_id_"}, {"key": {"email": 1}, "name": "email_1", "unique": "<ignore-diff>"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			_, err := m.haveTheseIndexesInCollectionOfDatabase(context.Background(), "customer", tc.database, &godog.DocString{Content: tc.expectedIndexes})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveNumberOfDocumentsInSearchResult(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs(readFixtures("resources/fixtures/customers.json"))
+
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		expected      int64
+		expectedError string
+	}{
+		{
+			scenario:      "no docs in context",
+			context:       context.Background(),
+			expectedError: `no documents are available in the search result, did you forget to search?`,
+		},
+		{
+			scenario: "empty docs in context",
+			context:  contextWithDocs(context.Background(), []bsoncore.Document{}),
+		},
+		{
+			scenario:      "mismatched",
+			context:       contextWithDocs(context.Background(), docs),
+			expected:      1,
+			expectedError: `there are 2 documents in the search result, expected 1`,
+		},
+		{
+			scenario: "matched",
+			context:  contextWithDocs(context.Background(), docs),
+			expected: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewManager()
+
+			_, err := m.haveNumberOfDocumentsInSearchResult(tc.context, tc.expected)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveDocumentsInSearchResult(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[{"name": "John"}]`))
+
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		expectedDocs  string
+		expectedError string
+	}{
+		{
+			scenario:      "no docs in context",
+			context:       context.Background(),
+			expectedError: `no documents are available in the search result, did you forget to search?`,
+		},
+		{
+			scenario:      "could not parse expected docs",
+			context:       contextWithDocs(context.Background(), []bsoncore.Document{}),
+			expectedDocs:  `[`,
+			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario:     "empty docs in context",
+			context:      contextWithDocs(context.Background(), []bsoncore.Document{}),
+			expectedDocs: `[]`,
+		},
+		{
+			scenario:     "mismatched",
+			context:      contextWithDocs(context.Background(), docs),
+			expectedDocs: `[{"name": "Jane"}]`,
+			expectedError: `not equal:
+ [
+   {
+-    "name": "Jane"
++    "name": "John"
+   }
+ ]
+`,
+		},
+		{
+			scenario:     "matched",
+			context:      contextWithDocs(context.Background(), docs),
+			expectedDocs: `[{"name": "John"}]`,
+		},
+		{
+			scenario:     "mismatched matchers",
+			context:      contextWithDocs(context.Background(), mustParseDocs([]byte(`[{"_id": {"$oid": "6250053966df8910f804c3a7"}, "total": {"$numberLong": "5"}}]`))),
+			expectedDocs: `[{"_id": "<is-oid>", "total": "<gt:10>"}]`,
+			expectedError: `not equal:
+ [
+   {
+     "_id": "<ignore-diff>",
+-    "total": "<gt:10>"
++    "total": {
++      "$numberLong": "5"
++    }
+   }
+ ]
+`,
+		},
+		{
+			scenario:     "matched matchers",
+			context:      contextWithDocs(context.Background(), mustParseDocs([]byte(`[{"_id": {"$oid": "6250053966df8910f804c3a7"}, "total": {"$numberLong": "12"}}]`))),
+			expectedDocs: `[{"_id": "<is-oid>", "total": "<gt:10>"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewManager()
+
+			_, err := m.haveDocumentsInSearchResult(tc.context, &godog.DocString{Content: tc.expectedDocs})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveDocumentsInSearchResult_WithDiffFormat(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDocs(context.Background(), mustParseDocs([]byte(`[{"_id": 1, "name": "John"}]`)))
+	data := &godog.DocString{Content: `[{"_id": 1, "name": "Jane"}]`}
+
+	testCases := []struct {
+		scenario      string
+		format        DiffFormat
+		expectedError string
+	}{
+		{
+			scenario: "json",
+			format:   DiffFormatJSON,
+			expectedError: `not equal:
+ [
+   {
+     "_id": {
+       "$numberInt": "1"
+     },
+-    "name": "Jane"
++    "name": "John"
+   }
+ ]
+`,
+		},
+		{
+			scenario: "report",
+			format:   DiffFormatReport,
+			expectedError: `0 missing, 0 unexpected and 1 mismatched document(s)
+
+document _id {"$numberInt":"1"}:
+  name: expected "Jane", got "John"
+`,
+		},
+		{
+			scenario: "text",
+			format:   DiffFormatText,
+			expectedError: `not equal:
+--- expected
++++ actual
+@@ -3,6 +3,6 @@
+         "_id": {
+             "$numberInt": "1"
+         },
+-        "name": "Jane"
++        "name": "John"
+     }
+ ]
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewManager(WithDiffFormat(tc.format))
+
+			_, err := m.haveDocumentsInSearchResult(ctx, data)
+
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestManager_HaveDocumentsInSearchResult_CompareDocumentsInAnyOrder(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[{"_id": 1, "name": "John"}, {"_id": 2, "name": "Jane"}]`))
+	ctx := contextWithSearchQuery(contextWithDocs(context.Background(), docs), searchQuery{Database: defaultDatabase, Collection: "customer", Find: &findQuery{Filter: bson.D{}}})
+
+	m := NewManager(WithDefaultDatabase(nil, CompareDocumentsInAnyOrder()))
+
+	_, err := m.haveDocumentsInSearchResult(ctx, &godog.DocString{Content: `[{"_id": 2, "name": "Jane"}, {"_id": 1, "name": "John"}]`})
+	assert.NoError(t, err)
+
+	_, err = m.haveDocumentsInSearchResult(ctx, &godog.DocString{Content: `[{"_id": 2, "name": "Jane"}]`})
+	assert.EqualError(t, err, `the search result does not have the expected documents: 0 expected document(s) have no match and 1 actual document(s) are unexpected
+
+unexpected document #1:
+{
+    "_id": {
+        "$numberInt": "1"
+    },
+    "name": "John"
+}
+`)
+
+	// The search result of a database that compares the documents in order.
+	_, err = NewManager(WithDefaultDatabase(nil)).haveDocumentsInSearchResult(ctx, &godog.DocString{Content: `[{"_id": 2, "name": "Jane"}, {"_id": 1, "name": "John"}]`})
+	assert.Error(t, err)
+}
+
+func TestManager_HaveDocumentsInAnyOrderInSearchResult(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[{"name": "John"}, {"name": "Jane"}]`))
+
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		expectedDocs  string
+		expectedError string
+	}{
+		{
+			scenario:      "no docs in context",
+			context:       context.Background(),
+			expectedError: `no documents are available in the search result, did you forget to search?`,
+		},
+		{
+			scenario:      "could not parse expected docs",
+			context:       contextWithDocs(context.Background(), []bsoncore.Document{}),
+			expectedDocs:  `[`,
+			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario:     "missing document",
+			context:      contextWithDocs(context.Background(), docs),
+			expectedDocs: `[{"name": "Jane"}]`,
+			expectedError: `the search result does not have the expected documents: 0 expected document(s) have no match and 1 actual document(s) are unexpected
+
+unexpected document #1:
+{
+    "name": "John"
+}
+`,
+		},
+		{
+			scenario:     "matched",
+			context:      contextWithDocs(context.Background(), docs),
+			expectedDocs: `[{"name": "Jane"}, {"name": "John"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewManager()
+
+			_, err := m.haveDocumentsInAnyOrderInSearchResult(tc.context, &godog.DocString{Content: tc.expectedDocs})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveRowsInSearchResult(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[{"name": "John"}, {"name": "Jane"}]`))
+
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		order         string
+		table         *godog.Table
+		expectedError string
+	}{
+		{
+			scenario:      "no docs in context",
+			context:       context.Background(),
+			expectedError: `no documents are available in the search result, did you forget to search?`,
+		},
+		{
+			scenario:      "no table",
+			context:       contextWithDocs(context.Background(), docs),
+			expectedError: `failed to parse expected rows: table is empty`,
+		},
+		{
+			scenario: "missing document in any order",
+			context:  contextWithDocs(context.Background(), docs),
+			order:    " in any order",
+			table:    newTable([]string{"name"}, []string{"Jane"}),
+			expectedError: `the search result does not have the expected documents: 0 expected document(s) have no match and 1 actual document(s) are unexpected
+
+unexpected document #1:
+{
+    "name": "John"
+}
+`,
+		},
+		{
+			scenario: "in any order",
+			context:  contextWithDocs(context.Background(), docs),
+			order:    " in any order",
+			table:    newTable([]string{"name"}, []string{"Jane"}, []string{"John"}),
+		},
+		{
+			scenario: "same order",
+			context:  contextWithDocs(context.Background(), docs),
+			table:    newTable([]string{"name"}, []string{"John"}, []string{"Jane"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewManager().haveRowsInSearchResult(tc.context, tc.order, tc.table)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_ContainDocumentsInSearchResult(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[{"name": "John", "age": 30}]`))
+
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		expectedDocs  string
+		expectedError string
+	}{
+		{
+			scenario:      "no docs in context",
+			context:       context.Background(),
+			expectedError: `no documents are available in the search result, did you forget to search?`,
+		},
+		{
+			scenario:      "could not parse expected docs",
+			context:       contextWithDocs(context.Background(), []bsoncore.Document{}),
+			expectedDocs:  `[`,
+			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
+		},
+		{
+			scenario:     "mismatched",
+			context:      contextWithDocs(context.Background(), docs),
+			expectedDocs: `[{"name": "Jane"}]`,
+			expectedError: `the search result does not contain the expected documents: could not find a match for 1 expected document(s)
+
+expected document #1:
+{
+    "name": "Jane"
+}
+closest candidate:
+{
+    "name": "John",
+    "age": {
+        "$numberInt": "30"
+    }
+}
+`,
+		},
+		{
+			scenario:     "duplicate expected docs",
+			context:      contextWithDocs(context.Background(), docs),
+			expectedDocs: `[{"name": "John"}, {"name": "John"}]`,
+			expectedError: `the search result does not contain the expected documents: could not find a match for 1 expected document(s)
+
+expected document #2:
+{
+    "name": "John"
+}
+closest candidate:
+{
+    "name": "John",
+    "age": {
+        "$numberInt": "30"
+    }
+}
+`,
+		},
+		{
+			scenario:     "matched",
+			context:      contextWithDocs(context.Background(), docs),
+			expectedDocs: `[{"name": "John"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewManager()
+
+			_, err := m.containDocumentsInSearchResult(tc.context, &godog.DocString{Content: tc.expectedDocs})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveNumberOfDocumentsAffectedByLastWrite(t *testing.T) {
+	t.Parallel()
+
+	result := writeResult{Matched: 2, Modified: 1, Upserted: 0, Deleted: 3}
+
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		expected      int64
+		kind          string
+		expectedError string
+	}{
+		{
+			scenario:      "no write result in context",
+			context:       context.Background(),
+			kind:          "matched",
+			expectedError: `no write result is available, did you forget to update or delete documents?`,
+		},
+		{
+			scenario:      "unknown kind",
+			context:       contextWithWriteResult(context.Background(), result),
+			kind:          "created",
+			expectedError: `unknown write result "created"`,
+		},
+		{
+			scenario:      "mismatched",
+			context:       contextWithWriteResult(context.Background(), result),
+			expected:      2,
+			kind:          "modified",
+			expectedError: `1 document(s) modified, expected 2`,
+		},
+		{
+			scenario: "matched",
+			context:  contextWithWriteResult(context.Background(), result),
+			expected: 2,
+			kind:     "matched",
+		},
+		{
+			scenario: "upserted",
+			context:  contextWithWriteResult(context.Background(), result),
+			kind:     "upserted",
+		},
+		{
+			scenario: "deleted",
+			context:  contextWithWriteResult(context.Background(), result),
+			expected: 3,
+			kind:     "deleted",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			m := NewManager()
+
+			_, err := m.haveNumberOfDocumentsAffectedByLastWrite(tc.context, tc.expected, tc.kind)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveDocumentsRejectedByCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	details := bson.Raw(bsoncore.NewDocumentBuilder().AppendInt32("failingDocumentId", 1).Build())
+
+	writeErrorResponse := func(code int, message string) bson.D {
+		return bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "writeErrors", Value: bson.A{bson.D{
+				{Key: "index", Value: 0},
+				{Key: "code", Value: code},
+				{Key: "errmsg", Value: message},
+				{Key: "errInfo", Value: details},
+			}}},
+		}
+	}
+
+	data := &godog.DocString{Content: `[{"age": 30}]`}
+
+	testCases := []struct {
+		scenario        string
+		database        string
+		data            *godog.DocString
+		result          bson.D
+		expectedContext context.Context // nolint: containedctx
+		expectedError   string
+	}{
+		{
+			scenario:        "missing database",
+			database:        "other",
+			expectedContext: context.Background(),
+			expectedError:   `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:        "no data",
+			database:        defaultDatabase,
+			expectedContext: context.Background(),
+			expectedError:   `failed to parse documents: data is nil`,
+		},
+		{
+			scenario:        "accepted",
+			database:        defaultDatabase,
+			data:            data,
+			result:          mtest.CreateSuccessResponse(),
+			expectedContext: context.Background(),
+			expectedError:   `documents are accepted by collection "customer", expected a document validation error`,
+		},
+		{
+			scenario:        "different error",
+			database:        defaultDatabase,
+			data:            data,
+			result:          writeErrorResponse(2, "bad value"),
+			expectedContext: context.Background(),
+			expectedError:   `documents are rejected by collection "customer" with other error (error details: {"failingDocumentId": {"$numberInt":"1"}}), expected a document validation error: could not insert documents into collection "customer": bulk write exception: write errors: [bad value: {"failingDocumentId": {"$numberInt":"1"}}]`,
+		},
+		{
+			scenario:        "client error",
+			database:        defaultDatabase,
+			data:            data,
+			result:          bson.D{{Key: "ok", Value: 0}},
+			expectedContext: context.Background(),
+			expectedError:   `could not store documents in collection "customer", expected a document validation error from the server: could not insert documents into collection "customer": command failed`,
+		},
+		{
+			scenario: "rejected",
+			database: defaultDatabase,
+			data:     data,
+			result:   writeErrorResponse(121, "Document failed validation"),
+			expectedContext: contextWithWriteError(context.Background(), writeError{
+				Kind:    writeErrorValidation,
+				Code:    121,
+				Message: "Document failed validation",
+				Details: details,
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result)
+
+			m := NewManager(WithDefaultDatabase(t.DB))
+
+			ctx, err := m.haveDocumentsRejectedByCollectionOfDatabase(context.Background(), "customer", tc.database, tc.data)
+
+			assert.Equal(t, tc.expectedContext, ctx)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_RememberFieldOfDocumentInSearchResult(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[
+		{"_id": {"$oid": "6250053966df8910f804c3a7"}, "name": "John Doe", "address": {"city": "City 1"}},
+		{"_id": {"$oid": "6250053966df8910f804c3a8"}, "name": "Jane Doe", "tags": ["vip", "new"]}
+	]`))
+
+	testCases := []struct {
+		scenario      string
+		docs          []bsoncore.Document
+		field         string
+		index         int
+		expected      string
+		expectedError string
+	}{
+		{
+			scenario:      "no search",
+			field:         "_id",
+			index:         1,
+			expectedError: `no documents are available in the search result, did you forget to search?`,
+		},
+		{
+			scenario:      "no document",
+			docs:          docs,
+			field:         "_id",
+			index:         3,
+			expectedError: `there is no document #3 in the result of 2 document(s)`,
+		},
+		{
+			scenario:      "no field",
+			docs:          docs,
+			field:         "address.city",
+			index:         2,
+			expectedError: `field "address.city" is not in document #2 of the result`,
+		},
+		{
+			scenario: "object id",
+			docs:     docs,
+			field:    "_id",
+			index:    2,
+			expected: `{"$oid":"6250053966df8910f804c3a8"}`,
+		},
+		{
+			scenario: "nested field",
+			docs:     docs,
+			field:    "address.city",
+			index:    1,
+			expected: `"City 1"`,
+		},
+		{
+			scenario: "array element",
+			docs:     docs,
+			field:    "tags.1",
+			index:    2,
+			expected: `"new"`,
+		},
+		{
+			scenario: "document",
+			docs:     docs,
+			field:    "address",
+			index:    1,
+			expected: `{"city":"City 1"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if tc.docs != nil {
+				ctx = contextWithDocs(ctx, tc.docs)
+			}
+
+			ctx, err := NewManager().rememberFieldOfDocumentInSearchResult(ctx, tc.field, tc.index, "$value")
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			v, ok := VariableFromContext(ctx, "value")
+			assert.True(t, ok)
+
+			actual, err := valueToExtJSON(v)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestManager_HaveFieldOfDocumentInSearchResult(t *testing.T) {
+	t.Parallel()
+
+	docs := mustParseDocs([]byte(`[
+		{"_id": {"$oid": "6250053966df8910f804c3a7"}, "name": "John Doe", "address": {"city": "City 1"}, "age": {"$numberLong": "30"}},
+		{"_id": {"$oid": "6250053966df8910f804c3a8"}, "name": "Jane Doe", "created": {"$date": {"$numberLong": "1649412000000"}}, "score": {"$numberDouble": "4.5"}}
+	]`))
+
+	testCases := []struct {
+		scenario      string
+		docs          []bsoncore.Document
+		field         string
+		index         int
+		expected      string
+		expectedError string
+	}{
+		{
+			scenario:      "no search",
+			field:         "name",
+			index:         1,
+			expected:      `"John Doe"`,
+			expectedError: `no documents are available in the search result, did you forget to search?`,
+		},
+		{
+			scenario:      "no field",
+			docs:          docs,
+			field:         "address.city",
+			index:         2,
+			expected:      `"City 2"`,
+			expectedError: `field "address.city" is not in document #2 of the result`,
+		},
+		{
+			scenario:      "invalid expected value",
+			docs:          docs,
+			field:         "name",
+			index:         1,
+			expected:      `John Doe`,
+			expectedError: `failed to parse expected value John Doe: invalid JSON input. Position: 6. Character: J`,
+		},
+		{
+			scenario:      "mismatched",
+			docs:          docs,
+			field:         "address.city",
+			index:         1,
+			expected:      `"City 2"`,
+			expectedError: `field "address.city" of document #1 of the result is "City 1", expected "City 2"`,
+		},
+		{
+			scenario: "nested field",
+			docs:     docs,
+			field:    "address.city",
+			index:    1,
+			expected: `"City 1"`,
+		},
+		{
+			scenario: "long as a number",
+			docs:     docs,
+			field:    "age",
+			index:    1,
+			expected: `30`,
+		},
+		{
+			scenario: "double",
+			docs:     docs,
+			field:    "score",
+			index:    2,
+			expected: `4.5`,
+		},
+		{
+			scenario: "date",
+			docs:     docs,
+			field:    "created",
+			index:    2,
+			expected: `{"$date": "2022-04-08T10:00:00Z"}`,
+		},
+		{
+			scenario:      "date as a string",
+			docs:          docs,
+			field:         "created",
+			index:         2,
+			expected:      `"2022-04-08T10:00:00Z"`,
+			expectedError: `field "created" of document #2 of the result is {"$date":{"$numberLong":"1649412000000"}}, expected "2022-04-08T10:00:00Z"`,
+		},
+		{
+			scenario: "matcher",
+			docs:     docs,
+			field:    "_id",
+			index:    1,
+			expected: `"<is-oid>"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if tc.docs != nil {
+				ctx = contextWithDocs(ctx, tc.docs)
+			}
+
+			_, err := NewManager().haveFieldOfDocumentInSearchResult(ctx, tc.field, tc.index, tc.expected)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveFieldOfDocumentContainingInSearchResult(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithDocs(context.Background(), mustParseDocs([]byte(`[
+		{"name": "John Doe", "tags": ["vip", "new"], "scores": [{"$numberLong": "1"}, {"$numberInt": "2"}]}
+	]`)))
+
+	testCases := []struct {
+		scenario      string
+		field         string
+		expected      string
+		expectedError string
+	}{
+		{scenario: "array", field: "tags", expected: `"vip"`},
+		{scenario: "array of numbers", field: "scores", expected: `1`},
+		{scenario: "string", field: "name", expected: `"Doe"`},
+		{
+			scenario:      "mismatched array",
+			field:         "tags",
+			expected:      `"gold"`,
+			expectedError: `field "tags" of document #1 of the result is ["vip","new"], expected it to contain "gold"`,
+		},
+		{
+			scenario:      "not an array",
+			field:         "name",
+			expected:      `1`,
+			expectedError: `field "name" of document #1 of the result is "John Doe", expected it to contain {"$numberInt":"1"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewManager().haveFieldOfDocumentContainingInSearchResult(ctx, tc.field, 1, tc.expected)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveFieldInAllDocumentsOfSearchResult(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		expected      string
+		expectedError string
+	}{
+		{
+			scenario:      "no search",
+			context:       context.Background(),
+			expected:      `"active"`,
+			expectedError: `no documents are available in the search result, did you forget to search?`,
+		},
+		{
+			scenario:      "no documents",
+			context:       contextWithDocs(context.Background(), []bsoncore.Document{}),
+			expected:      `"active"`,
+			expectedError: `there are no documents in the result to have field "status"`,
+		},
+		{
+			scenario: "mismatched",
+			context: contextWithDocs(context.Background(), mustParseDocs([]byte(`[
+				{"status": "active"}, {"status": "inactive"}, {"name": "John Doe"}
+			]`))),
+			expected: `"active"`,
+			expectedError: `field "status" of 2 document(s) of the result is not "active":
+  document #2: "inactive"
+  document #3: no field
+`,
+		},
+		{
+			scenario: "matched",
+			context:  contextWithDocs(context.Background(), mustParseDocs([]byte(`[{"status": "active"}, {"status": "active"}]`))),
+			expected: `"active"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewManager().haveFieldInAllDocumentsOfSearchResult(tc.context, "status", tc.expected)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_HaveNumberOfDocumentsInNamedResult(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithNamedResult(context.Background(), "before", mustParseDocs([]byte(`[{"name": "John"}, {"name": "Jane"}]`)))
+
+	_, err := NewManager().haveNumberOfDocumentsInNamedResult(ctx, "before", 2)
+	assert.NoError(t, err)
+
+	_, err = NewManager().haveNumberOfDocumentsInNamedResult(ctx, "before", 3)
+	assert.EqualError(t, err, `there are 2 documents in the result "before", expected 3`)
+
+	_, err = NewManager().haveNumberOfDocumentsInNamedResult(ctx, "after", 2)
+	assert.EqualError(t, err, `no result "after" is available, did you forget to search as "after"?`)
+}
+
+func TestManager_HaveDocumentsInNamedResult(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithNamedResult(context.Background(), "before", mustParseDocs([]byte(`[{"name": "John"}]`)))
+
+	_, err := NewManager().haveDocumentsInNamedResult(ctx, "before", &godog.DocString{Content: `[{"name": "John"}]`})
+	assert.NoError(t, err)
+
+	_, err = NewManager(WithDiffFormat(DiffFormatReport)).haveDocumentsInNamedResult(ctx, "before", &godog.DocString{Content: `[{"name": "Jane"}]`})
+	assert.EqualError(t, err, `the result "before" does not have the expected documents: 0 missing, 0 unexpected and 1 mismatched document(s)
+
+document #1:
+  name: expected "Jane", got "John"
+`)
+
+	_, err = NewManager().haveDocumentsInNamedResult(ctx, "before", &godog.DocString{Content: `[`})
+	assert.EqualError(t, err, `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`)
+}
+
+func TestManager_HaveNamedResultEqualToNamedResult(t *testing.T) {
+	t.Parallel()
+
+	ctx := contextWithNamedResult(context.Background(), "before", mustParseDocs([]byte(`[
+		{"_id": 1, "name": "John", "version": 1, "audit": {"updated_by": "john", "updated_at": 1}, "items": [{"sku": "A", "price": 1}, "gift"]}
+	]`)))
+	ctx = contextWithNamedResult(ctx, "after", mustParseDocs([]byte(`[
+		{"_id": 1, "name": "John", "version": 2, "audit": {"updated_by": "john", "updated_at": 2}, "items": [{"sku": "A", "price": 2}, "gift"]}
+	]`)))
+
+	testCases := []struct {
+		scenario      string
+		name          string
+		otherName     string
+		exceptFields  string
+		expectedError string
+	}{
+		{
+			scenario:      "unknown result",
+			name:          "after",
+			otherName:     "unknown",
+			expectedError: `no result "unknown" is available, did you forget to search as "unknown"?`,
+		},
+		{
+			scenario:     "not equal",
+			name:         "after",
+			otherName:    "before",
+			exceptFields: "version",
+			expectedError: `the result "after" does not equal the result "before": 0 missing, 0 unexpected and 1 mismatched document(s)
+
+document _id {"$numberInt":"1"}:
+  audit.updated_at: expected {"$numberInt":"1"}, got {"$numberInt":"2"}
+  items.0.price: expected {"$numberInt":"1"}, got {"$numberInt":"2"}
+`,
+		},
+		{
+			scenario:     "equal except fields",
+			name:         "after",
+			otherName:    "before",
+			exceptFields: "version, audit.updated_at, items.price",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewManager(WithDiffFormat(DiffFormatReport)).haveNamedResultEqualToNamedResult(ctx, tc.name, tc.otherName, tc.exceptFields)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_TheseDocumentsAreStoredInCollectionOfDatabase_Variables(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("resolved", func(t *mtest.T) {
+		t.Parallel()
+
+		t.AddMockResponses(mtest.CreateSuccessResponse())
+
+		ctx := contextWithDocs(context.Background(), mustParseDocs([]byte(`[{"_id": {"$oid": "6250053966df8910f804c3a7"}}]`)))
+
+		m := NewManager(WithDefaultDatabase(t.DB))
+
+		ctx, err := m.rememberFieldOfDocumentInSearchResult(ctx, "_id", 1, "$customerID")
+		assert.NoError(t, err)
+
+		_, err = m.theseDocumentsAreStoredInCollectionOfDatabase(ctx, "order", defaultDatabase, &godog.DocString{
+			Content: `[{"_id": {"$oid": "6250053966df8910f804c3b1"}, "customer_id": "$customerID", "ref": "ORD-{{ .customerID }}"}]`,
+		})
+		assert.NoError(t, err)
+
+		docs, err := t.GetStartedEvent().Command.Lookup("documents").Array().Values()
+		assert.NoError(t, err)
+
+		if !assert.Len(t, docs, 1) {
+			return
+		}
+
+		assertjson.Equal(t, []byte(`{
+			"_id": {"$oid": "6250053966df8910f804c3b1"},
+			"customer_id": {"$oid": "6250053966df8910f804c3a7"},
+			"ref": "ORD-6250053966df8910f804c3a7"
+		}`), []byte(docs[0].Document().String()))
+	})
+}
+
+func TestManager_TheseDocumentsAreStoredInCollectionOfDatabase_Generators(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("docstring", func(t *mtest.T) {
+		t.Parallel()
+
+		t.AddMockResponses(mtest.CreateSuccessResponse())
+
+		m := NewManager(WithDefaultDatabase(t.DB))
+
+		ctx, err := m.theseDocumentsAreStoredInCollectionOfDatabase(context.Background(), "order", defaultDatabase, &godog.DocString{
+			Content: `[{"_id": {"$newOid": "order"}, "created": {"$now": {}}}]`,
+		})
+		assert.NoError(t, err)
+
+		oid, ok := VariableFromContext(ctx, "order")
+		if !assert.True(t, ok) {
+			return
+		}
+
+		docs, err := t.GetStartedEvent().Command.Lookup("documents").Array().Values()
+		assert.NoError(t, err)
+
+		if !assert.Len(t, docs, 1) {
+			return
+		}
+
+		assert.Equal(t, oid, docs[0].Document().Lookup("_id").ObjectID())
+		assert.Equal(t, bson.TypeDateTime, docs[0].Document().Lookup("created").Type)
+
+		// The alias is the same value in the later DocStrings.
+		for _, content := range []string{`{"_id": {"$newOid": "order"}}`, `{"_id": "$order"}`} {
+			_, data, err := m.resolveDocString(ctx, &godog.DocString{Content: content})
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, `{"_id": {"$oid": "`+valueToText(oid)+`"}}`, data.Content)
+		}
+	})
+
+	mt.Run("file", func(t *mtest.T) {
+		t.Parallel()
+
+		t.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		filePath := filepath.Join(t.TempDir(), "order.ndjson")
+
+		err := os.WriteFile(filePath, []byte(`{"_id": {"$newOid": "first"}, "key": {"$uuid": "key"}}
+{"_id": {"$newOid": "second"}, "key": {"$uuid": "key"}}
+`), 0o600)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		m := NewManager(WithDefaultDatabase(t.DB))
+
+		ctx, err := m.theseDocumentsFromFileAreStoredInCollectionOfDatabase(context.Background(), filePath, "order", defaultDatabase)
+		assert.NoError(t, err)
+
+		for _, alias := range []string{"first", "second", "key"} {
+			_, ok := VariableFromContext(ctx, alias)
+			assert.True(t, ok, alias)
+		}
+	})
+}
+
+func TestManager_HaveWriteErrorCode(t *testing.T) {
+	t.Parallel()
+
+	e := writeError{Kind: writeErrorDuplicateKey, Code: 11000, Message: "E11000 duplicate key error"}
+
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		expected      int
+		expectedError string
+	}{
+		{
+			scenario:      "no write error in context",
+			context:       context.Background(),
+			expectedError: `no write error is available, did you forget to store documents that should fail?`,
 		},
 		{
-			scenario:     "matched - ignored diff",
-			database:     defaultDatabase,
-			result:       createDocsResponse("db", "customer", docs),
-			expectedDocs: expectedIgnoredDiff,
+			scenario:      "mismatched",
+			context:       contextWithWriteError(context.Background(), e),
+			expected:      121,
+			expectedError: `the write error code is 11000, expected 121: E11000 duplicate key error`,
+		},
+		{
+			scenario: "matched",
+			context:  contextWithWriteError(context.Background(), e),
+			expected: 11000,
 		},
 	}
 
 	for _, tc := range testCases {
 		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
 
-		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+			_, err := NewManager().haveWriteErrorCode(tc.context, tc.expected)
 
-		mt.Run(tc.scenario, func(t *mtest.T) {
-			t.Parallel()
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
 
-			t.AddMockResponses(tc.result...)
+func TestManager_HaveWriteErrorMessage(t *testing.T) {
+	t.Parallel()
 
-			m := NewManager(WithDefaultDatabase(t.DB))
+	e := writeError{Kind: writeErrorDuplicateKey, Code: 11000, Message: "E11000 duplicate key error"}
 
-			_, err := m.haveOnlyTheseDocumentsAvailableInCollectionOfDatabase(context.Background(), "customer", tc.database, &godog.DocString{Content: tc.expectedDocs})
+	testCases := []struct {
+		scenario      string
+		context       context.Context // nolint: containedctx
+		expected      string
+		expectedError string
+	}{
+		{
+			scenario:      "no write error in context",
+			context:       context.Background(),
+			expectedError: `no write error is available, did you forget to store documents that should fail?`,
+		},
+		{
+			scenario:      "mismatched",
+			context:       contextWithWriteError(context.Background(), e),
+			expected:      "validation",
+			expectedError: `the write error message "E11000 duplicate key error" does not contain "validation"`,
+		},
+		{
+			scenario: "matched",
+			context:  contextWithWriteError(context.Background(), e),
+			expected: "duplicate key",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewManager().haveWriteErrorMessage(tc.context, tc.expected)
 
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
@@ -490,67 +4093,121 @@ func TestManager_HaveOnlyTheseDocumentsAvailableInCollectionOfDatabase(t *testin
 	}
 }
 
-func TestManager_HaveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(t *testing.T) {
+func TestManager_EventuallyHaveNumberOfDocumentsAvailableInCollectionOfDatabase(t *testing.T) {
 	t.Parallel()
 
-	docs := mustParseDocs(readFixtures("resources/fixtures/customers.json"))
+	const expectedNumberOfDocuments int64 = 5
 
 	testCases := []struct {
 		scenario      string
 		database      string
-		filePath      string
+		timeout       string
 		result        []bson.D
 		expectedError string
 	}{
 		{
-			scenario:      "file not found",
+			scenario:      "missing database",
 			database:      "other",
-			filePath:      "resources/fixtures/unknown.json",
-			expectedError: `open resources/fixtures/unknown.json: no such file or directory`,
+			expectedError: `mongo database "other" is not registered to the manager`,
+		},
+		{
+			scenario:      "invalid timeout",
+			database:      defaultDatabase,
+			timeout:       "5x",
+			result:        createCountResponse("db", "customer", 4),
+			expectedError: `invalid timeout: time: unknown unit "x" in duration "5x"`,
+		},
+		{
+			scenario:      "timed out",
+			database:      defaultDatabase,
+			timeout:       "1ms",
+			result:        createCountResponse("db", "customer", 4),
+			expectedError: `timed out after 1ms: collection "customer" has 4 document(s), expected 5`,
+		},
+		{
+			scenario: "matched immediately",
+			database: defaultDatabase,
+			timeout:  "1ms",
+			result:   createCountResponse("db", "customer", expectedNumberOfDocuments),
+		},
+		{
+			scenario: "matched eventually",
+			database: defaultDatabase,
+			result: append(
+				createCountResponse("db", "customer", 4),
+				createCountResponse("db", "customer", expectedNumberOfDocuments)...,
+			),
 		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
+			t.Parallel()
+
+			t.AddMockResponses(tc.result...)
+
+			m := NewManager(WithDefaultDatabase(t.DB), WithPollInterval(10*time.Millisecond), WithPollTimeout(time.Second))
+
+			_, err := m.eventuallyHaveNumberOfDocumentsAvailableInCollectionOfDatabase(context.Background(), expectedNumberOfDocuments, "customer", tc.database, tc.timeout)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestManager_EventuallyHaveOnlyTheseDocumentsAvailableInCollectionOfDatabase(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		database      string
+		timeout       string
+		result        []bson.D
+		expectedDocs  string
+		expectedError string
+	}{
 		{
 			scenario:      "missing database",
 			database:      "other",
-			filePath:      "resources/fixtures/empty.json",
 			expectedError: `mongo database "other" is not registered to the manager`,
 		},
 		{
 			scenario:      "could not parse expected docs",
 			database:      defaultDatabase,
-			filePath:      "resources/fixtures/malformed.json",
+			expectedDocs:  `[`,
 			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
 		},
 		{
-			scenario:      "find error",
-			database:      defaultDatabase,
-			filePath:      "resources/fixtures/empty.json",
-			result:        []bson.D{{{Key: "ok", Value: 0}}},
-			expectedError: `could not find documents in collection "customer": command failed`,
-		},
-		{
-			scenario: "mismatched",
-			database: defaultDatabase,
-			filePath: "resources/fixtures/empty.json",
-			result:   createCursorResponse("db", "customer", bson.D{{Key: "name", Value: "Jane"}}),
-			expectedError: `not equal:
+			scenario:     "timed out",
+			database:     defaultDatabase,
+			timeout:      "1ms",
+			result:       createCursorResponse("db", "customer", bson.D{{Key: "name", Value: "Jane"}}),
+			expectedDocs: `[{"name": "John"}]`,
+			expectedError: `timed out after 1ms: not equal:
  [
-+  {
+   {
+-    "name": "John"
 +    "name": "Jane"
-+  }
+   }
  ]
 `,
 		},
 		{
-			scenario: "matched - no documents",
-			database: defaultDatabase,
-			filePath: "resources/fixtures/empty.json",
-			result:   createCursorResponse("db", "customer"),
-		},
-		{
-			scenario: "matched - has documents",
+			scenario: "matched eventually",
 			database: defaultDatabase,
-			filePath: "resources/fixtures/customers.json",
-			result:   createDocsResponse("db", "customer", docs),
+			result: append(
+				createCursorResponse("db", "customer"),
+				createCursorResponse("db", "customer", bson.D{{Key: "name", Value: "John"}})...,
+			),
+			expectedDocs: `[{"name": "John"}]`,
 		},
 	}
 
@@ -564,9 +4221,9 @@ func TestManager_HaveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(t
 
 			t.AddMockResponses(tc.result...)
 
-			m := NewManager(WithDefaultDatabase(t.DB))
+			m := NewManager(WithDefaultDatabase(t.DB), WithPollInterval(10*time.Millisecond), WithPollTimeout(time.Second))
 
-			_, err := m.haveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(context.Background(), tc.filePath, "customer", tc.database)
+			_, err := m.eventuallyHaveOnlyTheseDocumentsAvailableInCollectionOfDatabase(context.Background(), "customer", tc.database, tc.timeout, &godog.DocString{Content: tc.expectedDocs})
 
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
@@ -577,47 +4234,67 @@ func TestManager_HaveOnlyTheseDocumentsFromFileAvailableInCollectionOfDatabase(t
 	}
 }
 
-func TestManager_HaveNumberOfDocumentsInSearchResult(t *testing.T) {
+func TestManager_EventuallyHaveNumberOfDocumentsInSearchResult(t *testing.T) {
 	t.Parallel()
 
 	docs := mustParseDocs(readFixtures("resources/fixtures/customers.json"))
+	search := searchQuery{Database: defaultDatabase, Collection: "customer", Find: &findQuery{Filter: bson.D{}}}
 
 	testCases := []struct {
-		scenario      string
-		context       context.Context // nolint: containedctx
-		expected      int64
-		expectedError string
+		scenario        string
+		context         context.Context // nolint: containedctx
+		timeout         string
+		result          []bson.D
+		expectedContext context.Context // nolint: containedctx
+		expectedError   string
 	}{
 		{
-			scenario:      "no docs in context",
-			context:       context.Background(),
-			expectedError: `no documents are available in the search result, did you forget to search?`,
+			scenario:        "no search in context",
+			context:         context.Background(),
+			expectedContext: context.Background(),
+			expectedError:   `no search is available to be refreshed, did you forget to search?`,
 		},
 		{
-			scenario: "empty docs in context",
-			context:  contextWithDocs(context.Background(), []bsoncore.Document{}),
+			scenario:        "missing database",
+			context:         contextWithSearchQuery(context.Background(), searchQuery{Database: "other", Collection: "customer", Find: &findQuery{}}),
+			timeout:         "1ms",
+			expectedContext: contextWithSearchQuery(context.Background(), searchQuery{Database: "other", Collection: "customer", Find: &findQuery{}}),
+			expectedError:   `timed out after 1ms: mongo database "other" is not registered to the manager`,
 		},
 		{
-			scenario:      "mismatched",
-			context:       contextWithDocs(context.Background(), docs),
-			expected:      1,
-			expectedError: `there are 2 documents in the search result, expected 1`,
+			scenario:        "timed out",
+			context:         contextWithSearchQuery(context.Background(), search),
+			timeout:         "1ms",
+			result:          createCursorResponse("db", "customer"),
+			expectedContext: contextWithSearchQuery(context.Background(), search),
+			expectedError:   `timed out after 1ms: there are 0 documents in the search result, expected 2`,
 		},
 		{
-			scenario: "matched",
-			context:  contextWithDocs(context.Background(), docs),
-			expected: 2,
+			scenario: "matched eventually",
+			context:  contextWithSearchQuery(context.Background(), search),
+			result: append(
+				createCursorResponse("db", "customer"),
+				createDocsResponse("db", "customer", docs)...,
+			),
+			expectedContext: contextWithDocs(contextWithSearchQuery(context.Background(), search), docs),
 		},
 	}
 
 	for _, tc := range testCases {
 		tc := tc
-		t.Run(tc.scenario, func(t *testing.T) {
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
 			t.Parallel()
 
-			m := NewManager()
+			t.AddMockResponses(tc.result...)
 
-			_, err := m.haveNumberOfDocumentsInSearchResult(tc.context, tc.expected)
+			m := NewManager(WithDefaultDatabase(t.DB), WithPollInterval(10*time.Millisecond), WithPollTimeout(time.Second))
+
+			ctx, err := m.eventuallyHaveNumberOfDocumentsInSearchResult(tc.context, 2, tc.timeout)
+
+			assert.Equal(t, tc.expectedContext, ctx)
 
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
@@ -628,61 +4305,63 @@ func TestManager_HaveNumberOfDocumentsInSearchResult(t *testing.T) {
 	}
 }
 
-func TestManager_HaveDocumentsInSearchResult(t *testing.T) {
+func TestManager_EventuallyHaveDocumentsInSearchResult(t *testing.T) {
 	t.Parallel()
 
-	docs := mustParseDocs([]byte(`[{"name": "John"}]`))
+	search := searchQuery{Database: defaultDatabase, Collection: "customer", Find: &findQuery{Filter: bson.D{}}}
+	aggregation := searchQuery{Database: defaultDatabase, Collection: "customer", Pipeline: mongo.Pipeline{}}
 
 	testCases := []struct {
 		scenario      string
 		context       context.Context // nolint: containedctx
+		timeout       string
+		result        []bson.D
 		expectedDocs  string
 		expectedError string
 	}{
 		{
-			scenario:      "no docs in context",
+			scenario:      "no search in context",
 			context:       context.Background(),
-			expectedError: `no documents are available in the search result, did you forget to search?`,
+			expectedError: `no search is available to be refreshed, did you forget to search?`,
 		},
 		{
 			scenario:      "could not parse expected docs",
-			context:       contextWithDocs(context.Background(), []bsoncore.Document{}),
+			context:       contextWithSearchQuery(context.Background(), search),
 			expectedDocs:  `[`,
 			expectedError: `failed to parse expected documents: error unmarshaling extjson: invalid JSON input; unexpected end of input at position 0`,
 		},
 		{
-			scenario:     "empty docs in context",
-			context:      contextWithDocs(context.Background(), []bsoncore.Document{}),
-			expectedDocs: `[]`,
-		},
-		{
-			scenario:     "mismatched",
-			context:      contextWithDocs(context.Background(), docs),
-			expectedDocs: `[{"name": "Jane"}]`,
-			expectedError: `not equal:
- [
-   {
--    "name": "Jane"
-+    "name": "John"
-   }
- ]
-`,
+			scenario:      "aggregate error",
+			context:       contextWithSearchQuery(context.Background(), aggregation),
+			timeout:       "1ms",
+			result:        []bson.D{{{Key: "ok", Value: 0}}},
+			expectedDocs:  `[{"name": "John"}]`,
+			expectedError: `timed out after 1ms: could not run aggregation on collection "customer": command failed`,
 		},
 		{
-			scenario:     "matched",
-			context:      contextWithDocs(context.Background(), docs),
+			scenario: "matched eventually",
+			context:  contextWithSearchQuery(context.Background(), search),
+			result: append(
+				createCursorResponse("db", "customer"),
+				createCursorResponse("db", "customer", bson.D{{Key: "name", Value: "John"}})...,
+			),
 			expectedDocs: `[{"name": "John"}]`,
 		},
 	}
 
 	for _, tc := range testCases {
 		tc := tc
-		t.Run(tc.scenario, func(t *testing.T) {
+
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+		mt.Run(tc.scenario, func(t *mtest.T) {
 			t.Parallel()
 
-			m := NewManager()
+			t.AddMockResponses(tc.result...)
 
-			_, err := m.haveDocumentsInSearchResult(tc.context, &godog.DocString{Content: tc.expectedDocs})
+			m := NewManager(WithDefaultDatabase(t.DB), WithPollInterval(10*time.Millisecond), WithPollTimeout(time.Second))
+
+			_, err := m.eventuallyHaveDocumentsInSearchResult(tc.context, tc.timeout, &godog.DocString{Content: tc.expectedDocs})
 
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
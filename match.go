@@ -0,0 +1,593 @@
+package mongosteps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+const (
+	ignoreDiff  = "<ignore-diff>"
+	ignoredDiff = "<ignored-diff>"
+)
+
+// matcherPattern matches the typed matchers of the expected documents, like "<is-oid>", "<gt:10>" or "<regex:^ORD->".
+var matcherPattern = regexp.MustCompile(`(?s)^<(is-oid|is-date|regex|gt|gte|lt|lte|len|any-of|date-within)(?::(.*))?>$`)
+
+// containsDocuments checks whether every expected document has a distinct matching actual document whose fields are a
+// superset of the expected fields.
+func containsDocuments(expected, actual []bsoncore.Document) error {
+	unmatched, _ := pairDocuments(expected, actual, true)
+
+	if len(unmatched) == 0 {
+		return nil
+	}
+
+	var report strings.Builder
+
+	for _, i := range unmatched {
+		exp := expected[i]
+
+		_, _ = fmt.Fprintf(&report, "\nexpected document #%d:\n%s\nclosest candidate:\n", i+1, prettyDocument(exp))
+
+		if c := findClosestDocument(exp, actual); c >= 0 {
+			report.WriteString(prettyDocument(actual[c]))
+		} else {
+			report.WriteString("none")
+		}
+
+		report.WriteString("\n")
+	}
+
+	return fmt.Errorf("could not find a match for %d expected document(s)\n%s", len(unmatched), report.String()) // nolint: goerr113
+}
+
+// equalDocumentsInAnyOrder checks whether the expected and actual documents are equal as multisets, irrespective of
+// their positions.
+func equalDocumentsInAnyOrder(expected, actual []bsoncore.Document) error {
+	unmatched, unexpected := pairDocuments(expected, actual, false)
+
+	if len(unmatched) == 0 && len(unexpected) == 0 {
+		return nil
+	}
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(&report, "%d expected document(s) have no match and %d actual document(s) are unexpected\n", len(unmatched), len(unexpected))
+
+	for _, i := range unmatched {
+		_, _ = fmt.Fprintf(&report, "\nexpected document #%d:\n%s\n", i+1, prettyDocument(expected[i]))
+	}
+
+	for _, i := range unexpected {
+		_, _ = fmt.Fprintf(&report, "\nunexpected document #%d:\n%s\n", i+1, prettyDocument(actual[i]))
+	}
+
+	return errors.New(report.String()) // nolint: goerr113
+}
+
+// pairDocuments pairs every expected document with a distinct actual document that matches it, and returns the indexes
+// of the expected and actual documents that are left without a pair. In partial mode, the actual documents may have
+// fields that are not in the expected documents.
+func pairDocuments(expected, actual []bsoncore.Document, partial bool) (unmatched []int, unexpected []int) {
+	matches := make([][]bool, len(expected))
+
+	for i := range expected {
+		matches[i] = make([]bool, len(actual))
+
+		for j := range actual {
+			matches[i][j] = matchDocument(expected[i], actual[j], partial)
+		}
+	}
+
+	// pairs[j] is the index of the expected document paired with the actual document j, or -1.
+	pairs := make([]int, len(actual))
+	for j := range pairs {
+		pairs[j] = -1
+	}
+
+	var augment func(i int, visited []bool) bool
+
+	augment = func(i int, visited []bool) bool {
+		for j := range actual {
+			if !matches[i][j] || visited[j] {
+				continue
+			}
+
+			visited[j] = true
+
+			if pairs[j] < 0 || augment(pairs[j], visited) {
+				pairs[j] = i
+
+				return true
+			}
+		}
+
+		return false
+	}
+
+	paired := make([]bool, len(expected))
+
+	for i := range expected {
+		paired[i] = augment(i, make([]bool, len(actual)))
+	}
+
+	for i, ok := range paired {
+		if !ok {
+			unmatched = append(unmatched, i)
+		}
+	}
+
+	for j, i := range pairs {
+		if i < 0 {
+			unexpected = append(unexpected, j)
+		}
+	}
+
+	return unmatched, unexpected
+}
+
+// findClosestDocument returns the index of the actual document that has the most top-level fields matching the
+// expected document, or -1 if there is no actual document.
+func findClosestDocument(expected bsoncore.Document, actual []bsoncore.Document) int {
+	elems, err := expected.Elements()
+	if err != nil {
+		return -1
+	}
+
+	closest, best := -1, -1
+
+	for i, act := range actual {
+		score := 0
+
+		for _, e := range elems {
+			if v, err := act.LookupErr(e.Key()); err == nil && matchValue(e.Value(), v, true) {
+				score++
+			}
+		}
+
+		if score > best {
+			closest, best = i, score
+		}
+	}
+
+	return closest
+}
+
+// matchDocument checks whether the actual document matches the expected document. In partial mode, the actual document
+// may have fields that are not in the expected document.
+func matchDocument(expected, actual bsoncore.Document, partial bool) bool {
+	expectedElems, err := expected.Elements()
+	if err != nil {
+		return false
+	}
+
+	if !partial {
+		actualElems, err := actual.Elements()
+		if err != nil || len(actualElems) != len(expectedElems) {
+			return false
+		}
+	}
+
+	for _, e := range expectedElems {
+		v, err := actual.LookupErr(e.Key())
+		if err != nil {
+			return false
+		}
+
+		if !matchValue(e.Value(), v, partial) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchValue checks whether the actual value matches the expected value.
+func matchValue(expected, actual bsoncore.Value, partial bool) bool {
+	if isIgnoreDiff(expected) {
+		return true
+	}
+
+	if name, arg, ok := parseMatcher(expected); ok {
+		return matchMatcher(name, arg, actual)
+	}
+
+	if expected.Type != actual.Type {
+		return false
+	}
+
+	switch expected.Type { // nolint: exhaustive
+	case bsontype.EmbeddedDocument:
+		return matchDocument(expected.Document(), actual.Document(), partial)
+
+	case bsontype.Array:
+		return matchArray(expected.Array(), actual.Array(), partial)
+
+	default:
+		return expected.Equal(actual)
+	}
+}
+
+// matchArray checks whether the actual array has the same length as the expected array and every element matches.
+func matchArray(expected, actual bsoncore.Array, partial bool) bool {
+	expectedValues, err := expected.Values()
+	if err != nil {
+		return false
+	}
+
+	actualValues, err := actual.Values()
+	if err != nil || len(actualValues) != len(expectedValues) {
+		return false
+	}
+
+	for i := range expectedValues {
+		if !matchValue(expectedValues[i], actualValues[i], partial) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isIgnoreDiff(v bsoncore.Value) bool {
+	s, ok := v.StringValueOK()
+
+	return ok && (s == ignoreDiff || s == ignoredDiff)
+}
+
+// matchFieldValue checks whether the actual value of a field matches the expected value. The numbers are compared by
+// their values, so 30 matches an int, a long or a double.
+func matchFieldValue(expected, actual bsoncore.Value) bool {
+	if e, ok := valueToNumber(expected); ok {
+		if isIntegerValue(expected) && isIntegerValue(actual) {
+			return expected.AsInt64() == actual.AsInt64()
+		}
+
+		a, ok := valueToNumber(actual)
+
+		return ok && a == e
+	}
+
+	return matchValue(expected, actual, false)
+}
+
+// containFieldValue checks whether the actual array has an element that matches the expected value, or whether the
+// actual string contains the expected string.
+func containFieldValue(expected, actual bsoncore.Value) bool {
+	switch actual.Type { // nolint: exhaustive
+	case bsontype.Array:
+		values, err := actual.Array().Values()
+		if err != nil {
+			return false
+		}
+
+		for _, v := range values {
+			if matchFieldValue(expected, v) {
+				return true
+			}
+		}
+
+	case bsontype.String:
+		s, ok := expected.StringValueOK()
+
+		return ok && strings.Contains(actual.StringValue(), s)
+	}
+
+	return false
+}
+
+func isIntegerValue(v bsoncore.Value) bool {
+	return v.Type == bsontype.Int32 || v.Type == bsontype.Int64
+}
+
+// parseMatcher returns the name and the argument of the matcher when the value is a matcher, like "<len:3>".
+func parseMatcher(v bsoncore.Value) (string, string, bool) {
+	s, ok := v.StringValueOK()
+	if !ok {
+		return "", "", false
+	}
+
+	match := matcherPattern.FindStringSubmatch(s)
+	if match == nil {
+		return "", "", false
+	}
+
+	return match[1], match[2], true
+}
+
+// matchMatcher checks whether the actual value satisfies the matcher. The matchers check the BSON values, so a date or
+// a long is compared as a date or as a number. A matcher with an invalid argument matches nothing.
+func matchMatcher(name, arg string, actual bsoncore.Value) bool {
+	switch name {
+	case "is-oid":
+		return actual.Type == bsontype.ObjectID
+
+	case "is-date":
+		return actual.Type == bsontype.DateTime
+
+	case "regex":
+		s, ok := actual.StringValueOK()
+		if !ok {
+			return false
+		}
+
+		re, err := regexp.Compile(arg)
+
+		return err == nil && re.MatchString(s)
+
+	case "gt", "gte", "lt", "lte":
+		cmp, ok := compareToMatcher(actual, arg)
+
+		return ok && map[string]bool{"gt": cmp > 0, "gte": cmp >= 0, "lt": cmp < 0, "lte": cmp <= 0}[name]
+
+	case "len":
+		expected, err := strconv.Atoi(arg)
+		if err != nil {
+			return false
+		}
+
+		actualLen, ok := valueLen(actual)
+
+		return ok && actualLen == expected
+
+	case "any-of":
+		text, ok := valueToMatcherText(actual)
+		if !ok {
+			return false
+		}
+
+		for _, option := range strings.Split(arg, ",") {
+			if strings.TrimSpace(option) == text {
+				return true
+			}
+		}
+
+	case "date-within":
+		return matchDateWithin(arg, actual)
+	}
+
+	return false
+}
+
+// compareToMatcher compares the actual value with the argument of a comparison matcher, a number, or a date in RFC 3339
+// format when the value is a date. It returns false when they can not be compared.
+func compareToMatcher(actual bsoncore.Value, arg string) (int, bool) {
+	switch actual.Type { // nolint: exhaustive
+	case bsontype.DateTime:
+		t, err := time.Parse(time.RFC3339Nano, arg)
+		if err != nil {
+			return 0, false
+		}
+
+		return compareInt64(actual.DateTime(), t.UnixMilli()), true
+
+	case bsontype.Int32, bsontype.Int64:
+		// The integers are compared as integers, so that the large longs keep their precision.
+		if limit, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			return compareInt64(actual.AsInt64(), limit), true
+		}
+	}
+
+	number, ok := valueToNumber(actual)
+	if !ok {
+		return 0, false
+	}
+
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	switch {
+	case number < limit:
+		return -1, true
+
+	case number > limit:
+		return 1, true
+	}
+
+	return 0, true
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// matchDateWithin checks whether the actual date is within the tolerance of the current date, like "<date-within:5m>",
+// or of a date in RFC 3339 format, like "<date-within:1s,2022-04-08T10:00:00Z>".
+func matchDateWithin(arg string, actual bsoncore.Value) bool {
+	if actual.Type != bsontype.DateTime {
+		return false
+	}
+
+	tolerance, reference := arg, time.Now()
+
+	if i := strings.Index(arg, ","); i >= 0 {
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(arg[i+1:]))
+		if err != nil {
+			return false
+		}
+
+		tolerance, reference = arg[:i], t
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(tolerance))
+	if err != nil {
+		return false
+	}
+
+	diff := time.UnixMilli(actual.DateTime()).Sub(reference)
+	if diff < 0 {
+		diff = -diff
+	}
+
+	return diff <= d
+}
+
+// valueToNumber returns the value of a number of any BSON type.
+func valueToNumber(v bsoncore.Value) (float64, bool) {
+	switch v.Type { // nolint: exhaustive
+	case bsontype.Int32, bsontype.Int64:
+		return float64(v.AsInt64()), true
+
+	case bsontype.Double:
+		return v.Double(), true
+
+	case bsontype.Decimal128:
+		f, err := strconv.ParseFloat(v.Decimal128().String(), 64)
+
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// valueLen returns the number of elements of an array, the number of fields of a document, or the number of characters
+// of a string.
+func valueLen(v bsoncore.Value) (int, bool) {
+	switch v.Type { // nolint: exhaustive
+	case bsontype.Array:
+		values, err := v.Array().Values()
+
+		return len(values), err == nil
+
+	case bsontype.EmbeddedDocument:
+		elems, err := v.Document().Elements()
+
+		return len(elems), err == nil
+
+	case bsontype.String:
+		return utf8.RuneCountInString(v.StringValue()), true
+	}
+
+	return 0, false
+}
+
+// valueToMatcherText returns the text of a scalar value, to compare with the options of "<any-of:a,b>".
+func valueToMatcherText(v bsoncore.Value) (string, bool) {
+	switch v.Type { // nolint: exhaustive
+	case bsontype.String:
+		return v.StringValue(), true
+
+	case bsontype.Int32, bsontype.Int64:
+		return strconv.FormatInt(v.AsInt64(), 10), true
+
+	case bsontype.Double:
+		return strconv.FormatFloat(v.Double(), 'f', -1, 64), true
+
+	case bsontype.Decimal128:
+		return v.Decimal128().String(), true
+
+	case bsontype.Boolean:
+		return strconv.FormatBool(v.Boolean()), true
+
+	case bsontype.ObjectID:
+		return v.ObjectID().Hex(), true
+
+	case bsontype.Null:
+		return "null", true
+	}
+
+	return "", false
+}
+
+// applyMatchers replaces the matchers of the expected document that are satisfied by the actual document, at the same
+// paths, with <ignore-diff>, so that a comparison of the JSON documents only reports the matchers that are not.
+func applyMatchers(expected, actual bsoncore.Document) bsoncore.Document {
+	elems, err := expected.Elements()
+	if err != nil {
+		return expected
+	}
+
+	idx, doc := bsoncore.AppendDocumentStart(nil)
+
+	for _, e := range elems {
+		v := e.Value()
+
+		if a, err := actual.LookupErr(e.Key()); err == nil {
+			v = applyMatchersToValue(v, a)
+		}
+
+		doc = bsoncore.AppendValueElement(doc, e.Key(), v)
+	}
+
+	doc, err = bsoncore.AppendDocumentEnd(doc, idx)
+	if err != nil {
+		return expected
+	}
+
+	return doc
+}
+
+func applyMatchersToValue(expected, actual bsoncore.Value) bsoncore.Value {
+	if name, arg, ok := parseMatcher(expected); ok {
+		if matchMatcher(name, arg, actual) {
+			return bsoncore.Value{Type: bsontype.String, Data: bsoncore.AppendString(nil, ignoreDiff)}
+		}
+
+		return expected
+	}
+
+	switch {
+	case expected.Type == bsontype.EmbeddedDocument && actual.Type == bsontype.EmbeddedDocument:
+		return bsoncore.Value{Type: bsontype.EmbeddedDocument, Data: applyMatchers(expected.Document(), actual.Document())}
+
+	case expected.Type == bsontype.Array && actual.Type == bsontype.Array:
+		expectedValues, err := expected.Array().Values()
+		if err != nil {
+			return expected
+		}
+
+		actualValues, err := actual.Array().Values()
+		if err != nil {
+			return expected
+		}
+
+		idx, arr := bsoncore.AppendArrayStart(nil)
+
+		for i, v := range expectedValues {
+			if i < len(actualValues) {
+				v = applyMatchersToValue(v, actualValues[i])
+			}
+
+			arr = bsoncore.AppendValueElement(arr, strconv.Itoa(i), v)
+		}
+
+		arr, err = bsoncore.AppendArrayEnd(arr, idx)
+		if err != nil {
+			return expected
+		}
+
+		return bsoncore.Value{Type: bsontype.Array, Data: arr}
+	}
+
+	return expected
+}
+
+func prettyDocument(doc bsoncore.Document) string {
+	var buf bytes.Buffer
+
+	if err := json.Indent(&buf, []byte(doc.String()), "", "    "); err != nil {
+		return doc.String()
+	}
+
+	return buf.String()
+}
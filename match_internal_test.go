@@ -0,0 +1,207 @@
+package mongosteps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestMatchDocument(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		expected string
+		actual   string
+		partial  bool
+		matched  bool
+	}{
+		{
+			scenario: "equal",
+			expected: `{"name": "John", "age": 30}`,
+			actual:   `{"age": 30, "name": "John"}`,
+			matched:  true,
+		},
+		{
+			scenario: "extra field",
+			expected: `{"name": "John"}`,
+			actual:   `{"name": "John", "age": 30}`,
+		},
+		{
+			scenario: "extra field in partial mode",
+			expected: `{"name": "John"}`,
+			actual:   `{"name": "John", "age": 30}`,
+			partial:  true,
+			matched:  true,
+		},
+		{
+			scenario: "missing field in partial mode",
+			expected: `{"name": "John", "age": 30}`,
+			actual:   `{"name": "John"}`,
+			partial:  true,
+		},
+		{
+			scenario: "different type",
+			expected: `{"age": 30}`,
+			actual:   `{"age": {"$numberLong": "30"}}`,
+			partial:  true,
+		},
+		{
+			scenario: "ignore diff",
+			expected: `{"_id": "<ignore-diff>", "address": "<ignored-diff>"}`,
+			actual:   `{"_id": {"$oid": "6250053966df8910f804c3a7"}, "address": {"city": "City 1"}}`,
+			matched:  true,
+		},
+		{
+			scenario: "nested document in partial mode",
+			expected: `{"address": {"city": "City 1"}}`,
+			actual:   `{"address": {"street": "Street 1", "city": "City 1"}}`,
+			partial:  true,
+			matched:  true,
+		},
+		{
+			scenario: "array in partial mode",
+			expected: `{"tags": [{"name": "vip"}, "<ignore-diff>"]}`,
+			actual:   `{"tags": [{"name": "vip", "since": 2020}, "new"]}`,
+			partial:  true,
+			matched:  true,
+		},
+		{
+			scenario: "matchers",
+			expected: `{"_id": "<is-oid>", "ref": "<regex:^ORD-\\d+$>", "tags": "<len:2>", "total": "<gt:10>", "status": "<any-of:new,paid>"}`,
+			actual:   `{"_id": {"$oid": "6250053966df8910f804c3a7"}, "ref": "ORD-42", "tags": ["vip", "new"], "total": {"$numberLong": "12"}, "status": "paid"}`,
+			matched:  true,
+		},
+		{
+			scenario: "unsatisfied matcher",
+			expected: `{"_id": "<is-oid>", "total": "<gt:10>"}`,
+			actual:   `{"_id": {"$oid": "6250053966df8910f804c3a7"}, "total": 10}`,
+		},
+		{
+			scenario: "array with different length",
+			expected: `{"tags": ["vip"]}`,
+			actual:   `{"tags": ["vip", "new"]}`,
+			partial:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			expected := mustParseDocs([]byte("[" + tc.expected + "]"))[0]
+			actual := mustParseDocs([]byte("[" + tc.actual + "]"))[0]
+
+			assert.Equal(t, tc.matched, matchDocument(expected, actual, tc.partial))
+		})
+	}
+}
+
+func TestMatchMatcher(t *testing.T) {
+	t.Parallel()
+
+	recent := primitive.NewDateTimeFromTime(time.Now().Add(-time.Minute))
+
+	testCases := []struct {
+		scenario string
+		matcher  string
+		actual   interface{}
+		matched  bool
+	}{
+		{scenario: "is-oid", matcher: "<is-oid>", actual: primitive.NewObjectID(), matched: true},
+		{scenario: "is-oid with a hex string", matcher: "<is-oid>", actual: "6250053966df8910f804c3a7"},
+		{scenario: "is-date", matcher: "<is-date>", actual: recent, matched: true},
+		{scenario: "is-date with a string", matcher: "<is-date>", actual: "2022-04-08T10:00:00Z"},
+		{scenario: "regex", matcher: `<regex:^ORD-\d+$>`, actual: "ORD-42", matched: true},
+		{scenario: "regex mismatched", matcher: `<regex:^ORD-\d+$>`, actual: "INV-42"},
+		{scenario: "regex with a number", matcher: `<regex:^\d+$>`, actual: int32(42)},
+		{scenario: "invalid regex", matcher: `<regex:(>`, actual: "("},
+		{scenario: "gt int", matcher: "<gt:10>", actual: int32(11), matched: true},
+		{scenario: "gt equal", matcher: "<gt:10>", actual: int32(10)},
+		{scenario: "gte long", matcher: "<gte:9007199254740993>", actual: int64(9007199254740993), matched: true},
+		{scenario: "gt long precision", matcher: "<gt:9007199254740992>", actual: int64(9007199254740993), matched: true},
+		{scenario: "lt double", matcher: "<lt:4.5>", actual: 4.4, matched: true},
+		{scenario: "lte decimal", matcher: "<lte:4.5>", actual: primitive.NewDecimal128(0, 45), matched: true},
+		{scenario: "gt date", matcher: "<gt:2022-04-08T10:00:00Z>", actual: recent, matched: true},
+		{scenario: "lt date", matcher: "<lt:2022-04-08T10:00:00Z>", actual: recent},
+		{scenario: "gt string", matcher: "<gt:10>", actual: "11"},
+		{scenario: "gt invalid", matcher: "<gt:ten>", actual: int32(11)},
+		{scenario: "len array", matcher: "<len:3>", actual: primitive.A{"a", "b", "c"}, matched: true},
+		{scenario: "len string", matcher: "<len:3>", actual: "été", matched: true},
+		{scenario: "len document", matcher: "<len:1>", actual: primitive.D{{Key: "a", Value: 1}}, matched: true},
+		{scenario: "len mismatched", matcher: "<len:3>", actual: primitive.A{"a"}},
+		{scenario: "len of a number", matcher: "<len:3>", actual: int32(3)},
+		{scenario: "any-of string", matcher: "<any-of:new, paid>", actual: "paid", matched: true},
+		{scenario: "any-of number", matcher: "<any-of:1,2>", actual: int64(2), matched: true},
+		{scenario: "any-of mismatched", matcher: "<any-of:new,paid>", actual: "cancelled"},
+		{scenario: "date-within now", matcher: "<date-within:5m>", actual: recent, matched: true},
+		{scenario: "date-within now too far", matcher: "<date-within:30s>", actual: recent},
+		{scenario: "date-within date", matcher: "<date-within:1s,2022-04-08T10:00:00Z>", actual: primitive.NewDateTimeFromTime(time.Date(2022, 4, 8, 10, 0, 0, 500000000, time.UTC)), matched: true},
+		{scenario: "date-within invalid", matcher: "<date-within:soon>", actual: recent},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			name, arg, ok := parseMatcher(mustBSONValue(tc.matcher))
+			if !assert.True(t, ok) {
+				return
+			}
+
+			assert.Equal(t, tc.matched, matchMatcher(name, arg, mustBSONValue(tc.actual)))
+		})
+	}
+}
+
+func TestParseMatcher(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []interface{}{"<unknown>", "<is-oid", "is-oid", "<ignore-diff>", int32(42)} {
+		_, _, ok := parseMatcher(mustBSONValue(v))
+		assert.False(t, ok, v)
+	}
+}
+
+func TestApplyMatchers(t *testing.T) {
+	t.Parallel()
+
+	expected := mustParseDocs([]byte(`[{
+		"_id": "<is-oid>",
+		"total": "<gt:100>",
+		"address": {"city": "<regex:^City>"},
+		"tags": ["<len:3>", "<is-date>"],
+		"missing": "<is-oid>"
+	}]`))[0]
+
+	actual := mustParseDocs([]byte(`[{
+		"_id": {"$oid": "6250053966df8910f804c3a7"},
+		"total": 42,
+		"address": {"city": "City 1"},
+		"tags": ["vip", "new"]
+	}]`))[0]
+
+	assert.JSONEq(t, `{
+		"_id": "<ignore-diff>",
+		"total": "<gt:100>",
+		"address": {"city": "<ignore-diff>"},
+		"tags": ["<ignore-diff>", "<is-date>"],
+		"missing": "<is-oid>"
+	}`, applyMatchers(expected, actual).String())
+}
+
+func mustBSONValue(v interface{}) bsoncore.Value {
+	t, data, err := bson.MarshalValue(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return bsoncore.Value{Type: t, Data: data}
+}
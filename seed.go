@@ -0,0 +1,152 @@
+package mongosteps
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// seedManifestNames are the file names of the manifest of a fixture directory, by priority.
+var seedManifestNames = []string{"manifest.yaml", "manifest.yml", "manifest.json"}
+
+// seedFileExtensions are the extensions of the files of documents in a fixture directory.
+var seedFileExtensions = []string{".json", ".yaml", ".yml", ".ndjson", ".jsonl"}
+
+// seedManifest controls how a database is seeded from a fixture directory.
+type seedManifest struct {
+	// Order is the order of the collections that are seeded first, the others are seeded after them by name.
+	Order []string `yaml:"order"`
+	// Truncate is the collections that are truncated before seeding.
+	Truncate []string `yaml:"truncate"`
+}
+
+// seedFile is a file of documents for a collection in a fixture directory.
+type seedFile struct {
+	Collection string
+	Path       string
+}
+
+// seedPlan is the files to store, in order, and the collections to truncate before, for seeding a database.
+type seedPlan struct {
+	Files    []seedFile
+	Truncate []string
+}
+
+// collections returns the collections that are seeded.
+func (p seedPlan) collections() []string {
+	collections := make([]string, len(p.Files))
+
+	for i, f := range p.Files {
+		collections[i] = f.Collection
+	}
+
+	return collections
+}
+
+// readSeedDirectory reads a fixture directory where the name of each file of documents is the name of its collection,
+// like customer.json, order.yaml or invoice.ndjson.
+func readSeedDirectory(dirPath string) (seedPlan, error) {
+	entries, err := os.ReadDir(path.Clean(dirPath))
+	if err != nil {
+		return seedPlan{}, err
+	}
+
+	manifest, manifestName, err := readSeedManifest(dirPath)
+	if err != nil {
+		return seedPlan{}, err
+	}
+
+	files := make(map[string]seedFile)
+	names := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || e.Name() == manifestName {
+			continue
+		}
+
+		collection, ok := seedFileCollection(e.Name())
+		if !ok {
+			return seedPlan{}, fmt.Errorf("unsupported file %q", e.Name()) // nolint: goerr113
+		}
+
+		if f, ok := files[collection]; ok {
+			return seedPlan{}, fmt.Errorf("files %q and %q are for the same collection %q", filepath.Base(f.Path), e.Name(), collection) // nolint: goerr113
+		}
+
+		files[collection] = seedFile{Collection: collection, Path: filepath.Join(dirPath, e.Name())}
+		names = append(names, collection)
+	}
+
+	sort.Strings(names)
+
+	plan := seedPlan{
+		Files:    make([]seedFile, 0, len(names)),
+		Truncate: manifest.Truncate,
+	}
+
+	for _, collection := range manifest.Order {
+		f, ok := files[collection]
+		if !ok {
+			return seedPlan{}, fmt.Errorf("collection %q in manifest has no file", collection) // nolint: goerr113
+		}
+
+		plan.Files = append(plan.Files, f)
+
+		delete(files, collection)
+	}
+
+	for _, collection := range names {
+		if f, ok := files[collection]; ok {
+			plan.Files = append(plan.Files, f)
+		}
+	}
+
+	return plan, nil
+}
+
+// readSeedManifest reads the manifest of a fixture directory, if there is one, and returns its file name.
+func readSeedManifest(dirPath string) (seedManifest, string, error) {
+	for _, name := range seedManifestNames {
+		f, err := os.Open(filepath.Join(dirPath, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return seedManifest{}, "", err
+		}
+
+		defer f.Close() // nolint: errcheck
+
+		var manifest seedManifest
+
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+
+		if err := dec.Decode(&manifest); err != nil && !errors.Is(err, io.EOF) {
+			return seedManifest{}, "", fmt.Errorf("could not read manifest %q: %w", name, err)
+		}
+
+		return manifest, name, nil
+	}
+
+	return seedManifest{}, "", nil
+}
+
+// seedFileCollection returns the collection of a file of documents in a fixture directory.
+func seedFileCollection(name string) (string, bool) {
+	ext := filepath.Ext(name)
+
+	if ext == "" || !containsString(seedFileExtensions, strings.ToLower(ext)) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(name, ext), true
+}
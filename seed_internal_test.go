@@ -0,0 +1,109 @@
+package mongosteps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadSeedDirectory(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario         string
+		files            map[string]string
+		expectedFiles    []string
+		expectedTruncate []string
+		expectedError    string
+	}{
+		{
+			scenario:      "no manifest",
+			files:         map[string]string{"order.yaml": "", "customer.json": "", ".gitkeep": "", "invoice.ndjson": ""},
+			expectedFiles: []string{"customer.json", "invoice.ndjson", "order.yaml"},
+		},
+		{
+			scenario:         "manifest",
+			files:            map[string]string{"order.yaml": "", "customer.json": "", "invoice.ndjson": "", "manifest.yml": "order: [order]\ntruncate: [audit]\n"},
+			expectedFiles:    []string{"order.yaml", "customer.json", "invoice.ndjson"},
+			expectedTruncate: []string{"audit"},
+		},
+		{
+			scenario:      "empty manifest",
+			files:         map[string]string{"customer.json": "", "manifest.json": ""},
+			expectedFiles: []string{"customer.json"},
+		},
+		{
+			scenario:      "dotted collection",
+			files:         map[string]string{"app.customer.json": ""},
+			expectedFiles: []string{"app.customer.json"},
+		},
+		{
+			scenario:      "unsupported file",
+			files:         map[string]string{"customer.json": "", "README.md": ""},
+			expectedError: `unsupported file "README.md"`,
+		},
+		{
+			scenario:      "same collection",
+			files:         map[string]string{"customer.json": "", "customer.yaml": ""},
+			expectedError: `files "customer.json" and "customer.yaml" are for the same collection "customer"`,
+		},
+		{
+			scenario:      "unknown collection in manifest",
+			files:         map[string]string{"customer.json": "", "manifest.yaml": "order: [order]"},
+			expectedError: `collection "order" in manifest has no file`,
+		},
+		{
+			scenario:      "unknown field in manifest",
+			files:         map[string]string{"customer.json": "", "manifest.yaml": "drop: [order]"},
+			expectedError: "could not read manifest \"manifest.yaml\": yaml: unmarshal errors:\n  line 1: field drop not found in type mongosteps.seedManifest",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			for name, content := range tc.files {
+				err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+				if !assert.NoError(t, err) {
+					return
+				}
+			}
+
+			plan, err := readSeedDirectory(dir)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			actualFiles := make([]string, len(plan.Files))
+
+			for i, f := range plan.Files {
+				assert.Equal(t, dir, filepath.Dir(f.Path))
+
+				actualFiles[i] = filepath.Base(f.Path)
+			}
+
+			assert.Equal(t, tc.expectedFiles, actualFiles)
+			assert.Equal(t, tc.expectedTruncate, plan.Truncate)
+		})
+	}
+}
+
+func TestReadSeedDirectory_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := readSeedDirectory("resources/fixtures/seed/unknown")
+
+	assert.EqualError(t, err, "open resources/fixtures/seed/unknown: no such file or directory")
+}
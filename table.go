@@ -0,0 +1,139 @@
+package mongosteps
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+const (
+	cellObjectIDPrefix = "oid:"
+	cellDatePrefix     = "date:"
+)
+
+var jsonNumberPattern = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?$`)
+
+// tableToDocs converts a table to documents. The header row has the field paths, a dotted path builds nested
+// documents, and every other row is a document. An empty cell leaves the field out of the document.
+func tableToDocs(table *godog.Table) ([]bsoncore.Document, error) {
+	if table == nil || len(table.Rows) == 0 {
+		return nil, errors.New("table is empty") // nolint: goerr113
+	}
+
+	header := make([][]string, len(table.Rows[0].Cells))
+
+	for i, cell := range table.Rows[0].Cells {
+		if cell.Value == "" {
+			return nil, fmt.Errorf("column #%d has no field", i+1) // nolint: goerr113
+		}
+
+		header[i] = strings.Split(cell.Value, ".")
+	}
+
+	docs := make([]bsoncore.Document, 0, len(table.Rows)-1)
+
+	for i, row := range table.Rows[1:] {
+		doc := bson.D{}
+
+		for j, cell := range row.Cells {
+			if cell.Value == "" {
+				continue
+			}
+
+			v, err := cellToValue(cell.Value)
+			if err != nil {
+				return nil, fmt.Errorf("row #%d, field %q: %w", i+1, table.Rows[0].Cells[j].Value, err)
+			}
+
+			if doc, err = setField(doc, header[j], v); err != nil {
+				return nil, fmt.Errorf("row #%d, field %q: %w", i+1, table.Rows[0].Cells[j].Value, err)
+			}
+		}
+
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("row #%d: %w", i+1, err)
+		}
+
+		docs = append(docs, raw)
+	}
+
+	return docs, nil
+}
+
+// cellToValue converts the text of a cell to a value. The text is a JSON value when it is a number, a boolean, null,
+// a quoted string, an object or an array, an ObjectID with the "oid:" prefix, a date with the "date:" prefix, or else
+// a plain string.
+func cellToValue(cell string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(cell, cellObjectIDPrefix):
+		oid, err := primitive.ObjectIDFromHex(strings.TrimPrefix(cell, cellObjectIDPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid object id %q: %w", cell, err)
+		}
+
+		return oid, nil
+
+	case strings.HasPrefix(cell, cellDatePrefix):
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimPrefix(cell, cellDatePrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", cell, err)
+		}
+
+		return primitive.NewDateTimeFromTime(t), nil
+
+	case cell == "true", cell == "false", cell == "null", jsonNumberPattern.MatchString(cell),
+		strings.HasPrefix(cell, "{"), strings.HasPrefix(cell, "["), strings.HasPrefix(cell, `"`):
+		var doc bson.D
+
+		if err := bson.UnmarshalExtJSON([]byte(`{"v": `+cell+`}`), true, &doc); err != nil {
+			return nil, fmt.Errorf("invalid JSON value %q: %w", cell, err)
+		}
+
+		return doc[0].Value, nil
+
+	default:
+		return cell, nil
+	}
+}
+
+// setField sets the value at the path in the document, creating the nested documents that are missing.
+func setField(doc bson.D, path []string, v interface{}) (bson.D, error) {
+	for i, e := range doc {
+		if e.Key != path[0] {
+			continue
+		}
+
+		nested, ok := e.Value.(bson.D)
+		if len(path) == 1 || !ok {
+			return nil, fmt.Errorf("field %q is set more than once", path[0]) // nolint: goerr113
+		}
+
+		nested, err := setField(nested, path[1:], v)
+		if err != nil {
+			return nil, err
+		}
+
+		doc[i].Value = nested
+
+		return doc, nil
+	}
+
+	if len(path) == 1 {
+		return append(doc, bson.E{Key: path[0], Value: v}), nil
+	}
+
+	nested, err := setField(bson.D{}, path[1:], v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(doc, bson.E{Key: path[0], Value: nested}), nil
+}
@@ -0,0 +1,122 @@
+package mongosteps
+
+import (
+	"testing"
+
+	"github.com/cucumber/godog"
+	messages "github.com/cucumber/messages/go/v21"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableToDocs(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		table         *godog.Table
+		expected      string
+		expectedError string
+	}{
+		{
+			scenario:      "no table",
+			expectedError: `table is empty`,
+		},
+		{
+			scenario:      "missing field",
+			table:         newTable([]string{"name", ""}),
+			expectedError: `column #2 has no field`,
+		},
+		{
+			scenario: "header only",
+			table:    newTable([]string{"name"}),
+			expected: `[]`,
+		},
+		{
+			scenario: "typed cells",
+			table: newTable(
+				[]string{"_id", "name", "age", "score", "active", "deleted", "tags", "quoted", "created"},
+				[]string{"oid:6250053966df8910f804c3a7", "John Doe", "42", "4.5", "true", "null", `["a", "b"]`, `"42"`, "date:2022-04-08T10:00:00Z"},
+			),
+			expected: `[{
+				"_id": {"$oid": "6250053966df8910f804c3a7"},
+				"name": "John Doe",
+				"age": {"$numberInt": "42"},
+				"score": {"$numberDouble": "4.5"},
+				"active": true,
+				"deleted": null,
+				"tags": ["a", "b"],
+				"quoted": "42",
+				"created": {"$date": {"$numberLong": "1649412000000"}}
+			}]`,
+		},
+		{
+			scenario: "dotted paths",
+			table: newTable(
+				[]string{"name", "address.city", "address.geo.lat", "address.zip"},
+				[]string{"John Doe", "City 1", "1.5", "1000"},
+				[]string{"Jane Doe", "City 2", "", ""},
+			),
+			expected: `[
+				{"name": "John Doe", "address": {"city": "City 1", "geo": {"lat": {"$numberDouble": "1.5"}}, "zip": {"$numberInt": "1000"}}},
+				{"name": "Jane Doe", "address": {"city": "City 2"}}
+			]`,
+		},
+		{
+			scenario:      "conflicting fields",
+			table:         newTable([]string{"address", "address.city"}, []string{"home", "City 1"}),
+			expectedError: `row #1, field "address.city": field "address" is set more than once`,
+		},
+		{
+			scenario:      "invalid object id",
+			table:         newTable([]string{"_id"}, []string{"oid:42"}),
+			expectedError: `row #1, field "_id": invalid object id "oid:42": the provided hex string is not a valid ObjectID`,
+		},
+		{
+			scenario:      "invalid date",
+			table:         newTable([]string{"created"}, []string{"date:yesterday"}),
+			expectedError: `row #1, field "created": invalid date "date:yesterday": parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			scenario:      "invalid JSON",
+			table:         newTable([]string{"tags"}, []string{"[a"}),
+			expectedError: `row #1, field "tags": invalid JSON value "[a": invalid JSON input. Position: 7. Character: a`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			docs, err := tableToDocs(tc.table)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			actual, err := docsToExtJSON(docs)
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func newTable(rows ...[]string) *godog.Table {
+	table := &godog.Table{Rows: make([]*messages.PickleTableRow, len(rows))}
+
+	for i, row := range rows {
+		table.Rows[i] = &messages.PickleTableRow{Cells: make([]*messages.PickleTableCell, len(row))}
+
+		for j, cell := range row {
+			table.Rows[i].Cells[j] = &messages.PickleTableCell{Value: cell}
+		}
+	}
+
+	return table
+}
@@ -0,0 +1,364 @@
+package mongosteps
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	messages "github.com/cucumber/messages/go/v21"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// variablePattern matches a string value that is a variable, like "$customerID".
+	variablePattern = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)$`)
+
+	// templatePattern matches a placeholder of a variable in a text, like {{ .customerID }}.
+	templatePattern = regexp.MustCompile(`\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+type variablesCtxKey struct{}
+
+// VariableStore stores the variables of a scenario, so that the steps of other libraries can share them with the
+// documents of the mongo steps.
+type VariableStore interface {
+	// Variable returns the value of the variable in the scenario.
+	Variable(ctx context.Context, name string) (interface{}, bool)
+	// SetVariable sets the value of the variable in the scenario.
+	SetVariable(ctx context.Context, name string, value interface{}) context.Context
+}
+
+// contextVariableStore is the default VariableStore, the variables are in the context of the scenario.
+type contextVariableStore struct{}
+
+func (contextVariableStore) Variable(ctx context.Context, name string) (interface{}, bool) {
+	return VariableFromContext(ctx, name)
+}
+
+func (contextVariableStore) SetVariable(ctx context.Context, name string, value interface{}) context.Context {
+	return ContextWithVariable(ctx, name, value)
+}
+
+// ContextWithVariable sets the value of the variable in the context of the scenario, for the default VariableStore.
+func ContextWithVariable(ctx context.Context, name string, value interface{}) context.Context {
+	current, _ := ctx.Value(variablesCtxKey{}).(map[string]interface{}) // nolint: errcheck
+	variables := make(map[string]interface{}, len(current)+1)
+
+	for k, v := range current {
+		variables[k] = v
+	}
+
+	variables[variableName(name)] = value
+
+	return context.WithValue(ctx, variablesCtxKey{}, variables)
+}
+
+// VariableFromContext returns the value of the variable in the context of the scenario, for the default
+// VariableStore.
+func VariableFromContext(ctx context.Context, name string) (interface{}, bool) {
+	variables, _ := ctx.Value(variablesCtxKey{}).(map[string]interface{}) // nolint: errcheck
+	v, ok := variables[variableName(name)]
+
+	return v, ok
+}
+
+// variableName returns the name of a variable without its $ prefix, "$customerID" and "customerID" are the same
+// variable.
+func variableName(name string) string {
+	return strings.TrimPrefix(name, "$")
+}
+
+// resolveDocString replaces the variables and the generator expressions in the DocString. A {{ .name }} placeholder is
+// replaced by the text of the value anywhere in the content, a generator expression like {"$newOid": "order"} is
+// replaced by a generated value, and a "$name" string value is replaced by the value itself, with its type. The
+// aliases of the generated values are set as variables in the returned context.
+func (m *Manager) resolveDocString(ctx context.Context, data *godog.DocString) (context.Context, *godog.DocString, error) {
+	return m.resolveDocStringValues(ctx, data, true)
+}
+
+// resolveQueryDocString replaces the variables and the generator expressions in the DocString of a query or a pipeline,
+// as in resolveDocString, except the "$name" string values, which are field paths in the aggregation expressions.
+func (m *Manager) resolveQueryDocString(ctx context.Context, data *godog.DocString) (context.Context, *godog.DocString, error) {
+	return m.resolveDocStringValues(ctx, data, false)
+}
+
+// resolveDocStringValues replaces the variables and the generator expressions in the DocString, and the "$name" string
+// values unless they are left as they are.
+func (m *Manager) resolveDocStringValues(ctx context.Context, data *godog.DocString, values bool) (context.Context, *godog.DocString, error) {
+	if data == nil {
+		return ctx, nil, nil
+	}
+
+	content, err := m.resolveTemplate(ctx, data.Content)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	resolved := &godog.DocString{MediaType: data.MediaType, Content: content}
+
+	if !strings.Contains(content, "$") {
+		return ctx, resolved, nil
+	}
+
+	mediaType := data.MediaType
+
+	// The generator expressions and the values are replaced in ExtJSON.
+	if isYAMLMediaType(mediaType) {
+		converted, err := yamlToExtJSON([]byte(content))
+		if err != nil {
+			return ctx, resolved, nil
+		}
+
+		content, mediaType = string(converted), ""
+	}
+
+	content, generated, err := generateValues(content,
+		func(alias string) (interface{}, bool) {
+			return m.variables.Variable(ctx, alias)
+		},
+		func(alias string, v interface{}) {
+			ctx = m.variables.SetVariable(ctx, alias, v)
+		},
+	)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	if generated {
+		resolved.MediaType, resolved.Content = mediaType, content
+	}
+
+	if !values {
+		return ctx, resolved, nil
+	}
+
+	if r, ok := m.resolveValues(ctx, mediaType, content); ok {
+		resolved.MediaType, resolved.Content = r.MediaType, r.Content
+	}
+
+	return ctx, resolved, nil
+}
+
+// fileResolver returns a docStringResolver that resolves the content of a file as in resolveDocString, the context
+// with the aliases of the generated values is kept in ctx.
+func (m *Manager) fileResolver(ctx *context.Context) docStringResolver {
+	return func(data *godog.DocString) (*godog.DocString, error) {
+		var err error
+
+		*ctx, data, err = m.resolveDocString(*ctx, data)
+
+		return data, err
+	}
+}
+
+// resolveTable replaces the variables and the generator expressions in the cells of the table, as in
+// resolveDocString.
+func (m *Manager) resolveTable(ctx context.Context, table *godog.Table) (context.Context, *godog.Table, error) {
+	if table == nil {
+		return ctx, nil, nil
+	}
+
+	resolved := &godog.Table{Rows: make([]*messages.PickleTableRow, len(table.Rows))}
+
+	for i, row := range table.Rows {
+		resolved.Rows[i] = &messages.PickleTableRow{Cells: make([]*messages.PickleTableCell, len(row.Cells))}
+
+		for j, cell := range row.Cells {
+			value, err := m.resolveTemplate(ctx, cell.Value)
+			if err != nil {
+				return ctx, nil, err
+			}
+
+			// The header row has the fields, not values.
+			if i > 0 {
+				if ctx, value, err = m.resolveCell(ctx, value); err != nil {
+					return ctx, nil, err
+				}
+			}
+
+			resolved.Rows[i].Cells[j] = &messages.PickleTableCell{Value: value}
+		}
+	}
+
+	return ctx, resolved, nil
+}
+
+// resolveCell replaces the variable or the generator expressions in the value of a cell.
+func (m *Manager) resolveCell(ctx context.Context, value string) (context.Context, string, error) {
+	if v, ok := m.variable(ctx, value); ok {
+		data, err := valueToExtJSON(v)
+
+		return ctx, data, err
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		return ctx, value, nil
+	}
+
+	value, _, err := generateValues(value,
+		func(alias string) (interface{}, bool) {
+			return m.variables.Variable(ctx, alias)
+		},
+		func(alias string, v interface{}) {
+			ctx = m.variables.SetVariable(ctx, alias, v)
+		},
+	)
+
+	return ctx, value, err
+}
+
+// resolveTemplate replaces the {{ .name }} placeholders with the text of the values of the variables.
+func (m *Manager) resolveTemplate(ctx context.Context, content string) (string, error) {
+	if !strings.Contains(content, "{{") {
+		return content, nil
+	}
+
+	var err error
+
+	result := templatePattern.ReplaceAllStringFunc(content, func(placeholder string) string {
+		name := templatePattern.FindStringSubmatch(placeholder)[1]
+
+		v, ok := m.variables.Variable(ctx, name)
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("variable %q is not defined", name) // nolint: goerr113
+			}
+
+			return placeholder
+		}
+
+		return valueToText(v)
+	})
+
+	return result, err
+}
+
+// resolveValues replaces the "$name" string values of the variables that are defined with their values in the ExtJSON
+// content, it returns the content in canonical ExtJSON when there is a replacement.
+func (m *Manager) resolveValues(ctx context.Context, mediaType, content string) (*godog.DocString, bool) {
+	parts := []string{content}
+
+	if isNDJSONMediaType(mediaType) {
+		parts = strings.Split(content, "\n")
+	}
+
+	replaced := false
+
+	for i, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		var wrapper bson.D
+
+		// The content may be a document or an array of documents, so it is wrapped to be parsed at once.
+		if err := bson.UnmarshalExtJSON([]byte(`{"v": `+part+`}`), false, &wrapper); err != nil {
+			return nil, false
+		}
+
+		v, ok := m.resolveValue(ctx, wrapper[0].Value)
+		if !ok {
+			continue
+		}
+
+		data, err := valueToExtJSON(v)
+		if err != nil {
+			return nil, false
+		}
+
+		parts[i], replaced = data, true
+	}
+
+	if !replaced {
+		return nil, false
+	}
+
+	return &godog.DocString{MediaType: mediaType, Content: strings.Join(parts, "\n")}, true
+}
+
+// resolveValue replaces the variables in the value, it returns false when there is nothing to replace.
+func (m *Manager) resolveValue(ctx context.Context, v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		return m.variable(ctx, v)
+
+	case bson.D:
+		replaced := false
+
+		for i, e := range v {
+			if r, ok := m.resolveValue(ctx, e.Value); ok {
+				v[i].Value, replaced = r, true
+			}
+		}
+
+		return v, replaced
+
+	case bson.A:
+		replaced := false
+
+		for i, e := range v {
+			if r, ok := m.resolveValue(ctx, e); ok {
+				v[i], replaced = r, true
+			}
+		}
+
+		return v, replaced
+	}
+
+	return nil, false
+}
+
+// variable returns the value of the variable when the text is a variable that is defined, like "$customerID".
+func (m *Manager) variable(ctx context.Context, text string) (interface{}, bool) {
+	match := variablePattern.FindStringSubmatch(text)
+	if match == nil {
+		return nil, false
+	}
+
+	return m.variables.Variable(ctx, match[1])
+}
+
+// valueToExtJSON converts a value to canonical ExtJSON.
+func valueToExtJSON(v interface{}) (string, error) {
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, true, false)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling value: %w", err)
+	}
+
+	data = bytes.TrimPrefix(data, []byte(`{"v":`))
+	data = bytes.TrimSuffix(data, []byte(`}`))
+
+	return string(data), nil
+}
+
+// valueToText converts a value to the text of a {{ .name }} placeholder.
+func valueToText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+
+	case primitive.ObjectID:
+		return v.Hex()
+
+	case primitive.Binary:
+		if v.Subtype == bson.TypeBinaryUUID && len(v.Data) == 16 {
+			return fmt.Sprintf("%x-%x-%x-%x-%x", v.Data[0:4], v.Data[4:6], v.Data[6:8], v.Data[8:10], v.Data[10:16])
+		}
+
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+
+	case nil:
+		return "null"
+	}
+
+	return fmt.Sprint(v)
+}
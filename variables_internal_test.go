@@ -0,0 +1,146 @@
+package mongosteps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestContextWithVariable(t *testing.T) {
+	t.Parallel()
+
+	ctx := ContextWithVariable(context.Background(), "$customerID", "42")
+	other := ContextWithVariable(ctx, "name", "John Doe")
+
+	v, ok := VariableFromContext(ctx, "customerID")
+	assert.True(t, ok)
+	assert.Equal(t, "42", v)
+
+	_, ok = VariableFromContext(ctx, "name")
+	assert.False(t, ok)
+
+	v, ok = VariableFromContext(other, "$name")
+	assert.True(t, ok)
+	assert.Equal(t, "John Doe", v)
+}
+
+func TestManager_ResolveDocString(t *testing.T) {
+	t.Parallel()
+
+	oid, err := primitive.ObjectIDFromHex("6250053966df8910f804c3a7")
+	assert.NoError(t, err)
+
+	ctx := ContextWithVariable(context.Background(), "customerID", oid)
+	ctx = ContextWithVariable(ctx, "name", "John Doe")
+	ctx = ContextWithVariable(ctx, "age", int32(30))
+	ctx = ContextWithVariable(ctx, "createdAt", primitive.NewDateTimeFromTime(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	testCases := []struct {
+		scenario          string
+		data              *godog.DocString
+		expected          string
+		expectedMediaType string
+		expectedError     string
+	}{
+		{
+			scenario: "no variables",
+			data:     &godog.DocString{Content: `[{"$set": {"name": "$unknown"}}]`},
+			expected: `[{"$set": {"name": "$unknown"}}]`,
+		},
+		{
+			scenario: "values",
+			data:     &godog.DocString{Content: `[{"_id": "$customerID", "name": "$name", "tags": ["$age"], "$set": {"name": "$unknown"}}]`},
+			expected: `[{"_id":{"$oid":"6250053966df8910f804c3a7"},"name":"John Doe","tags":[{"$numberInt":"30"}],"$set":{"name":"$unknown"}}]`,
+		},
+		{
+			scenario: "templates",
+			data:     &godog.DocString{Content: `{"ref": "CUS-{{ .customerID }}", "age": {{.age}}, "since": "{{ .createdAt }}"}`},
+			expected: `{"ref": "CUS-6250053966df8910f804c3a7", "age": 30, "since": "2020-01-02T03:04:05Z"}`,
+		},
+		{
+			scenario:      "undefined template",
+			data:          &godog.DocString{Content: `{"name": "{{ .unknown }}"}`},
+			expectedError: `variable "unknown" is not defined`,
+		},
+		{
+			scenario: "yaml",
+			data:     &godog.DocString{MediaType: "yaml", Content: "_id: $customerID\nname: $name\n"},
+			expected: `{"_id":{"$oid":"6250053966df8910f804c3a7"},"name":"John Doe"}`,
+		},
+		{
+			scenario:          "ndjson",
+			data:              &godog.DocString{MediaType: "ndjson", Content: "{\"_id\": \"$customerID\"}\n{\"name\": \"Jane Doe\"}\n"},
+			expected:          "{\"_id\":{\"$oid\":\"6250053966df8910f804c3a7\"}}\n{\"name\": \"Jane Doe\"}\n",
+			expectedMediaType: "ndjson",
+		},
+		{
+			scenario: "malformed",
+			data:     &godog.DocString{Content: `{"_id": "$customerID"`},
+			expected: `{"_id": "$customerID"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			_, actual, err := NewManager().resolveDocString(ctx, tc.data)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMediaType, actual.MediaType)
+			assert.Equal(t, tc.expected, actual.Content)
+		})
+	}
+}
+
+func TestManager_ResolveQueryDocString(t *testing.T) {
+	t.Parallel()
+
+	ctx := ContextWithVariable(context.Background(), "total", int32(30))
+	ctx = ContextWithVariable(ctx, "country", "Country 1")
+
+	_, actual, err := NewManager().resolveQueryDocString(ctx, &godog.DocString{
+		Content: `[{"$match": {"address.country": "{{ .country }}"}}, {"$group": {"_id": null, "total": {"$sum": "$total"}}}]`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"$match": {"address.country": "Country 1"}}, {"$group": {"_id": null, "total": {"$sum": "$total"}}}]`, actual.Content)
+}
+
+func TestManager_ResolveTable(t *testing.T) {
+	t.Parallel()
+
+	oid, err := primitive.ObjectIDFromHex("6250053966df8910f804c3a7")
+	assert.NoError(t, err)
+
+	ctx := ContextWithVariable(context.Background(), "customerID", oid)
+	ctx = ContextWithVariable(ctx, "name", "John")
+
+	_, table, err := NewManager().resolveTable(ctx, newTable(
+		[]string{"_id", "name", "$name"},
+		[]string{"$customerID", "{{ .name }} Doe", "$unknown"},
+	))
+	assert.NoError(t, err)
+
+	docs, err := tableToDocs(table)
+	assert.NoError(t, err)
+
+	result, err := docsToExtJSON(docs)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `[{"_id": {"$oid": "6250053966df8910f804c3a7"}, "name": "John Doe", "$name": "$unknown"}]`, string(result))
+
+	_, _, err = NewManager().resolveTable(ctx, newTable([]string{"name"}, []string{"{{ .unknown }}"}))
+	assert.EqualError(t, err, `variable "unknown" is not defined`)
+}
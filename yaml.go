@@ -0,0 +1,229 @@
+package mongosteps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const mediaTypeYAML = "yaml"
+
+// isYAMLMediaType checks whether the media type of a DocString is YAML.
+func isYAMLMediaType(mediaType string) bool {
+	switch strings.ToLower(mediaType) {
+	case "yaml", "yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// yamlToExtJSON converts a YAML document to canonical ExtJSON. The order of the keys is preserved, the ExtJSON type
+// wrappers like $oid or $date are written as YAML maps, and the YAML timestamps become dates.
+func yamlToExtJSON(data []byte) ([]byte, error) {
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("error unmarshaling yaml: %w", err)
+	}
+
+	if node.Kind == 0 {
+		return nil, errors.New("yaml document is empty") // nolint: goerr113
+	}
+
+	var buf bytes.Buffer
+
+	if err := writeYAMLNode(&buf, &node); err != nil {
+		return nil, fmt.Errorf("error converting yaml: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeYAMLNode(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return writeYAMLNode(buf, node.Content[0])
+
+	case yaml.AliasNode:
+		return writeYAMLNode(buf, node.Alias)
+
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+
+		for i, n := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := writeYAMLNode(buf, n); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte(']')
+
+		return nil
+
+	case yaml.MappingNode:
+		return writeYAMLMapping(buf, node)
+
+	default:
+		return writeYAMLScalar(buf, node)
+	}
+}
+
+func writeYAMLMapping(buf *bytes.Buffer, node *yaml.Node) error {
+	// A date written as {$date: 2022-04-08T10:00:00Z} is converted to the canonical form.
+	if len(node.Content) == 2 && node.Content[0].Value == "$date" && node.Content[1].Kind == yaml.ScalarNode {
+		t, err := yamlToTime(node.Content[1])
+		if err != nil {
+			return err
+		}
+
+		writeDate(buf, t)
+
+		return nil
+	}
+
+	buf.WriteByte('{')
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if key.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: key is not a string", key.Line) // nolint: goerr113
+		}
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		writeString(buf, key.Value)
+		buf.WriteByte(':')
+
+		// The ExtJSON wrappers have string values, even when the YAML value looks like a number.
+		if isStringWrapper(key.Value) && value.Kind == yaml.ScalarNode {
+			writeString(buf, value.Value)
+
+			continue
+		}
+
+		if err := writeYAMLNode(buf, value); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteByte('}')
+
+	return nil
+}
+
+func writeYAMLScalar(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!null":
+		buf.WriteString("null")
+
+	case "!!bool":
+		var v bool
+
+		if err := node.Decode(&v); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+
+		buf.WriteString(strconv.FormatBool(v))
+
+	case "!!int":
+		var v int64
+
+		if err := node.Decode(&v); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+
+		buf.WriteString(strconv.FormatInt(v, 10))
+
+	case "!!float":
+		var v float64
+
+		if err := node.Decode(&v); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+
+		_, _ = fmt.Fprintf(buf, `{"$numberDouble":%q}`, formatDouble(v))
+
+	case "!!timestamp":
+		t, err := yamlToTime(node)
+		if err != nil {
+			return err
+		}
+
+		writeDate(buf, t)
+
+	case "!!binary":
+		_, _ = fmt.Fprintf(buf, `{"$binary":{"base64":%q,"subType":"00"}}`, strings.Join(strings.Fields(node.Value), ""))
+
+	default:
+		writeString(buf, node.Value)
+	}
+
+	return nil
+}
+
+// yamlToTime converts a YAML timestamp, or a string in RFC 3339 format, to a time.
+func yamlToTime(node *yaml.Node) (time.Time, error) {
+	if node.ShortTag() == "!!timestamp" {
+		var t time.Time
+
+		if err := node.Decode(&t); err != nil {
+			return time.Time{}, fmt.Errorf("line %d: %w", node.Line, err)
+		}
+
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, node.Value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("line %d: invalid date %q: %w", node.Line, node.Value, err)
+	}
+
+	return t, nil
+}
+
+func isStringWrapper(key string) bool {
+	switch key {
+	case "$oid", "$symbol", "$numberInt", "$numberLong", "$numberDouble", "$numberDecimal":
+		return true
+	default:
+		return false
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s) // nolint: errcheck,errchkjson
+
+	buf.Write(b)
+}
+
+func writeDate(buf *bytes.Buffer, t time.Time) {
+	_, _ = fmt.Fprintf(buf, `{"$date":{"$numberLong":"%d"}}`, t.UnixMilli())
+}
+
+func formatDouble(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
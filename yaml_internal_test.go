@@ -0,0 +1,117 @@
+package mongosteps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYAMLToExtJSON(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		yaml          string
+		expected      string
+		expectedError string
+	}{
+		{
+			scenario:      "empty",
+			expectedError: `yaml document is empty`,
+		},
+		{
+			scenario:      "malformed",
+			yaml:          `[`,
+			expectedError: `error unmarshaling yaml: yaml: line 1: did not find expected node content`,
+		},
+		{
+			scenario: "scalars",
+			yaml: `
+name: John Doe
+age: 30
+score: 4.5
+active: true
+deleted: ~
+quoted: "42"
+`,
+			expected: `{
+				"name": "John Doe",
+				"age": 30,
+				"score": {"$numberDouble": "4.5"},
+				"active": true,
+				"deleted": null,
+				"quoted": "42"
+			}`,
+		},
+		{
+			scenario: "nested documents and arrays",
+			yaml: `
+- address:
+    city: City 1
+  tags: [a, b]
+`,
+			expected: `[{"address": {"city": "City 1"}, "tags": ["a", "b"]}]`,
+		},
+		{
+			scenario: "type wrappers",
+			yaml: `
+_id:
+  $oid: 6250053966df8910f804c3a7
+count:
+  $numberLong: 30
+created:
+  $date: 2022-04-08T10:00:00Z
+updated:
+  $date: "2022-04-08T10:00:00.5Z"
+deleted:
+  $date:
+    $numberLong: "1649412000000"
+`,
+			expected: `{
+				"_id": {"$oid": "6250053966df8910f804c3a7"},
+				"count": {"$numberLong": "30"},
+				"created": {"$date": {"$numberLong": "1649412000000"}},
+				"updated": {"$date": {"$numberLong": "1649412000500"}},
+				"deleted": {"$date": {"$numberLong": "1649412000000"}}
+			}`,
+		},
+		{
+			scenario: "timestamp",
+			yaml:     `created: 2022-04-08`,
+			expected: `{"created": {"$date": {"$numberLong": "1649376000000"}}}`,
+		},
+		{
+			scenario:      "invalid date",
+			yaml:          `created: {$date: yesterday}`,
+			expectedError: `error converting yaml: line 1: invalid date "yesterday": parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			scenario: "anchors",
+			yaml: `
+- &john
+  name: John Doe
+- *john
+`,
+			expected: `[{"name": "John Doe"}, {"name": "John Doe"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := yamlToExtJSON([]byte(tc.yaml))
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}